@@ -0,0 +1,604 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/lifecycle"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+// AuthSessionState is the lifecycle state of a pre-authorization
+type AuthSessionState string
+
+const (
+	// AuthSessionStateNew means no authorization was sent yet
+	AuthSessionStateNew AuthSessionState = "NEW"
+
+	// AuthSessionStateOpen means the authorization was sent and can be incremented, captured or voided
+	AuthSessionStateOpen AuthSessionState = "OPEN"
+
+	// AuthSessionStateCaptured means a post authorization was sent
+	AuthSessionStateCaptured AuthSessionState = "CAPTURED"
+
+	// AuthSessionStateVoided means a void was sent
+	AuthSessionStateVoided AuthSessionState = "VOIDED"
+)
+
+// String returns the state code
+func (s AuthSessionState) String() string {
+	return string(s)
+}
+
+// PostAuthTolerance bounds the post authorization total around the authorized total
+// Percent and Amount are how far it may exceed the authorized total, e.g. to allow tips; the larger of
+// both allowances applies. MinPercent is how far it may fall below. The zero value allows no excess
+// and sets no lower bound
+type PostAuthTolerance struct {
+	// Percent is the allowed excess in percent of the authorized total
+	Percent float64 `json:"percent,omitempty"`
+
+	// Amount is the allowed excess in cents
+	Amount int64 `json:"amount,omitempty"`
+
+	// MinPercent is the smallest allowed total in percent of the authorized total (0 means no lower bound)
+	MinPercent float64 `json:"minPercent,omitempty"`
+}
+
+// allowance returns the allowed excess over an authorized total
+func (t PostAuthTolerance) allowance(authorized int64) int64 {
+	allowed := int64(float64(authorized) * t.Percent / 100)
+	if t.Amount > allowed {
+		allowed = t.Amount
+	}
+	return allowed
+}
+
+// minimum returns the smallest allowed total for an authorized total
+func (t PostAuthTolerance) minimum(authorized int64) int64 {
+	return int64(math.Ceil(float64(authorized) * t.MinPercent / 100))
+}
+
+// AuthSessionStep is one call made by an AuthSession
+type AuthSessionStep struct {
+	// Operation is the operation, see constant.Operation*
+	Operation string `json:"operation"`
+
+	// TransactionID is the SUNBAY Nexus transaction ID, empty until known
+	TransactionID string `json:"transactionId,omitempty"`
+
+	// TransactionRequestID is the transaction request ID
+	TransactionRequestID string `json:"transactionRequestId"`
+
+	// Currency is the price currency, empty for voids
+	Currency string `json:"currency,omitempty"`
+
+	// Amount is the amount of the step in cents, 0 for voids
+	Amount int64 `json:"amount,omitempty"`
+
+	// Status is the last known transaction status, empty while the outcome is unknown
+	Status types.TransactionStatus `json:"status,omitempty"`
+
+	// Unresolved means the call failed without a definite outcome, e.g. with a network error, so the
+	// transaction may exist. The step counts towards the totals until Refresh or UpdateStatus resolves it
+	Unresolved bool `json:"unresolved,omitempty"`
+
+	// Time is when the step was sent
+	Time time.Time `json:"time"`
+}
+
+// counts reports whether the step counts towards the totals, i.e. it did not fail or close
+func (s AuthSessionStep) counts() bool {
+	return s.Status != types.TransactionStatusFail && s.Status != types.TransactionStatusClosed
+}
+
+// AuthTotals are the running amounts of an AuthSession in one currency, in cents
+// Steps that failed or closed are excluded
+type AuthTotals struct {
+	// Authorized is the amount of the initial authorization
+	Authorized int64
+
+	// Incremented is the sum of incremental authorizations
+	Incremented int64
+
+	// Captured is the post authorization amount
+	Captured int64
+}
+
+// Total returns the authorized total including increments
+func (t AuthTotals) Total() int64 {
+	return t.Authorized + t.Incremented
+}
+
+// AuthSessionConfig holds the configuration for creating an AuthSession
+type AuthSessionConfig struct {
+	// AppID is the application ID (required)
+	AppID string
+
+	// MerchantID is the merchant ID (required)
+	MerchantID string
+
+	// TerminalSN is the terminal serial number used when a request leaves it empty (optional)
+	TerminalSN string
+
+	// Tolerance bounds the post authorization amount (optional, defaults to no excess)
+	Tolerance PostAuthTolerance
+}
+
+// AuthSession drives a pre-authorization through Auth, IncrementalAuth and finally PostAuth or Void,
+// filling in the original transaction identifiers and tracking the amounts per currency
+// One call runs at a time; the session stays readable while it is in flight. A call failing without a
+// definite outcome is recorded as an unresolved step, and no further call is allowed until Refresh
+// resolves it. It can be serialized with encoding/json and resumed with ResumeAuthSession; a call
+// still in flight is not included. It is safe for concurrent use
+type AuthSession struct {
+	client *NexusClient
+
+	mu       sync.Mutex
+	data     authSessionData
+	inFlight bool
+}
+
+// authSessionData is the serialized form of an AuthSession
+type authSessionData struct {
+	AppID                string            `json:"appId"`
+	MerchantID           string            `json:"merchantId"`
+	TerminalSN           string            `json:"terminalSn,omitempty"`
+	Tolerance            PostAuthTolerance `json:"tolerance"`
+	State                AuthSessionState  `json:"state"`
+	TransactionID        string            `json:"transactionId,omitempty"`
+	TransactionRequestID string            `json:"transactionRequestId,omitempty"`
+	Steps                []AuthSessionStep `json:"steps,omitempty"`
+}
+
+// NewAuthSession creates an AuthSession using the client
+func (c *NexusClient) NewAuthSession(config *AuthSessionConfig) (*AuthSession, error) {
+	if config == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"AuthSessionConfig cannot be nil",
+			"",
+		)
+	}
+	if config.AppID == "" || config.MerchantID == "" {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"AppID and MerchantID cannot be empty",
+			"",
+		)
+	}
+
+	return &AuthSession{
+		client: c,
+		data: authSessionData{
+			AppID:      config.AppID,
+			MerchantID: config.MerchantID,
+			TerminalSN: config.TerminalSN,
+			Tolerance:  config.Tolerance,
+			State:      AuthSessionStateNew,
+		},
+	}, nil
+}
+
+// ResumeAuthSession restores an AuthSession serialized with json.Marshal, e.g. after a restart
+func (c *NexusClient) ResumeAuthSession(data []byte) (*AuthSession, error) {
+	s := &AuthSession{client: c}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (s *AuthSession) MarshalJSON() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.Marshal(s.data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *AuthSession) UnmarshalJSON(data []byte) error {
+	var d authSessionData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return fmt.Errorf("parse auth session: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = d
+	return nil
+}
+
+// State returns the lifecycle state
+func (s *AuthSession) State() AuthSessionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.State
+}
+
+// TransactionID returns the transaction ID of the initial authorization, empty until known
+func (s *AuthSession) TransactionID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.TransactionID
+}
+
+// TransactionRequestID returns the request ID of the initial authorization
+func (s *AuthSession) TransactionRequestID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.TransactionRequestID
+}
+
+// Steps returns the calls made so far
+func (s *AuthSession) Steps() []AuthSessionStep {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuthSessionStep(nil), s.data.Steps...)
+}
+
+// Totals returns the running amounts in a currency
+func (s *AuthSession) Totals(currency string) AuthTotals {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totals(currency)
+}
+
+func (s *AuthSession) totals(currency string) AuthTotals {
+	var t AuthTotals
+	for _, step := range s.data.Steps {
+		if step.Currency != currency || !step.counts() {
+			continue
+		}
+		switch step.Operation {
+		case constant.OperationAuth:
+			t.Authorized += step.Amount
+		case constant.OperationIncrementalAuth:
+			t.Incremented += step.Amount
+		case constant.OperationPostAuth:
+			t.Captured += step.Amount
+		}
+	}
+	return t
+}
+
+// MaxPostAuthAmount returns the largest post authorization total allowed in a currency
+func (s *AuthSession) MaxPostAuthAmount(currency string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := s.totals(currency).Total()
+	return total + s.data.Tolerance.allowance(total)
+}
+
+// MinPostAuthAmount returns the smallest post authorization total allowed in a currency
+func (s *AuthSession) MinPostAuthAmount(currency string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Tolerance.minimum(s.totals(currency).Total())
+}
+
+// Auth sends the initial authorization
+// AppID, MerchantID and an empty TerminalSN are filled in from the session
+func (s *AuthSession) Auth(ctx context.Context, req *request.AuthRequest, opts ...CallOption) (*response.AuthResponse, error) {
+	if req == nil || req.Amount == nil || req.Amount.OrderAmount == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"AuthRequest with Amount.OrderAmount is required",
+			"",
+		)
+	}
+
+	s.mu.Lock()
+	if err := s.begin(constant.OperationAuth, AuthSessionStateNew); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	req.AppID, req.MerchantID = s.data.AppID, s.data.MerchantID
+	if req.TerminalSN == "" {
+		req.TerminalSN = s.data.TerminalSN
+	}
+	s.mu.Unlock()
+
+	resp, err := s.client.Auth(ctx, req, opts...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight = false
+	if err != nil && !outcomeUnknown(err) {
+		return nil, err
+	}
+	s.data.State = AuthSessionStateOpen
+	s.data.TransactionRequestID = req.TransactionRequestID
+	step := s.record(constant.OperationAuth, req.TransactionRequestID, req.Amount.PriceCurrency, *req.Amount.OrderAmount, err)
+	if resp != nil {
+		s.data.TransactionID = resp.TransactionID
+		step.TransactionID, step.Status = resp.TransactionID, types.TransactionStatus(resp.TransactionStatus)
+	}
+	return resp, err
+}
+
+// IncrementalAuth increases the authorized amount
+// The original transaction identifiers are filled in from the session
+func (s *AuthSession) IncrementalAuth(ctx context.Context, req *request.IncrementalAuthRequest, opts ...CallOption) (*response.IncrementalAuthResponse, error) {
+	if req == nil || req.Amount == nil || req.Amount.OrderAmount == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"IncrementalAuthRequest with Amount.OrderAmount is required",
+			"",
+		)
+	}
+
+	s.mu.Lock()
+	if err := s.begin(constant.OperationIncrementalAuth, AuthSessionStateOpen); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if err := s.checkCurrency(req.Amount.PriceCurrency); err != nil {
+		s.inFlight = false
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.fillOriginal(&req.AppID, &req.MerchantID, &req.TerminalSN, &req.OriginalTransactionID, &req.OriginalTransactionRequestID)
+	s.mu.Unlock()
+
+	resp, err := s.client.IncrementalAuth(ctx, req, opts...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight = false
+	if err != nil && !outcomeUnknown(err) {
+		return nil, err
+	}
+	step := s.record(constant.OperationIncrementalAuth, req.TransactionRequestID, req.Amount.PriceCurrency, *req.Amount.OrderAmount, err)
+	if resp != nil {
+		step.TransactionID, step.Status = resp.TransactionID, types.TransactionStatus(resp.TransactionStatus)
+	}
+	return resp, err
+}
+
+// PostAuth captures the authorization
+// The total of order, tip, tax and surcharge amounts must lie between MinPostAuthAmount and
+// MaxPostAuthAmount. The original transaction identifiers are filled in from the session
+func (s *AuthSession) PostAuth(ctx context.Context, req *request.PostAuthRequest, opts ...CallOption) (*response.PostAuthResponse, error) {
+	if req == nil || req.Amount == nil || req.Amount.OrderAmount == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"PostAuthRequest with Amount.OrderAmount is required",
+			"",
+		)
+	}
+
+	s.mu.Lock()
+	if err := s.begin(constant.OperationPostAuth, AuthSessionStateOpen); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	currency := req.Amount.PriceCurrency
+	amount := *req.Amount.OrderAmount + int64Value(req.Amount.TipAmount) + int64Value(req.Amount.TaxAmount) + int64Value(req.Amount.SurchargeAmount)
+	if err := s.checkPostAuthAmount(currency, amount); err != nil {
+		s.inFlight = false
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.fillOriginal(&req.AppID, &req.MerchantID, &req.TerminalSN, &req.OriginalTransactionID, &req.OriginalTransactionRequestID)
+	s.mu.Unlock()
+
+	resp, err := s.client.PostAuth(ctx, req, opts...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight = false
+	if err != nil && !outcomeUnknown(err) {
+		return nil, err
+	}
+	s.data.State = AuthSessionStateCaptured
+	step := s.record(constant.OperationPostAuth, req.TransactionRequestID, currency, amount, err)
+	if resp != nil {
+		step.TransactionID, step.Status = resp.TransactionID, types.TransactionStatus(resp.TransactionStatus)
+	}
+	return resp, err
+}
+
+// Void releases the authorization
+// The original transaction identifiers are filled in from the session
+func (s *AuthSession) Void(ctx context.Context, req *request.VoidRequest, opts ...CallOption) (*response.VoidResponse, error) {
+	if req == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"VoidRequest cannot be nil",
+			"",
+		)
+	}
+
+	s.mu.Lock()
+	if err := s.begin(constant.OperationVoid, AuthSessionStateOpen); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.fillOriginal(&req.AppID, &req.MerchantID, &req.TerminalSN, &req.OriginalTransactionID, &req.OriginalTransactionRequestID)
+	s.mu.Unlock()
+
+	resp, err := s.client.Void(ctx, req, opts...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight = false
+	if err != nil && !outcomeUnknown(err) {
+		return nil, err
+	}
+	s.data.State = AuthSessionStateVoided
+	step := s.record(constant.OperationVoid, req.TransactionRequestID, "", 0, err)
+	if resp != nil {
+		step.TransactionID, step.Status = resp.TransactionID, types.TransactionStatus(resp.TransactionStatus)
+	}
+	return resp, err
+}
+
+// UpdateStatus records the final status of a step, e.g. from a notification or WaitForFinalStatus
+// Failed or closed steps no longer count towards the totals, and a failed or closed post authorization
+// or void reopens the session. It reports whether a step matched
+func (s *AuthSession) UpdateStatus(transactionRequestID string, transactionID string, status types.TransactionStatus) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.Steps {
+		step := &s.data.Steps[i]
+		if step.TransactionRequestID != transactionRequestID {
+			continue
+		}
+		step.Status = status
+		step.Unresolved = false
+		if transactionID != "" {
+			step.TransactionID = transactionID
+			if step.Operation == constant.OperationAuth {
+				s.data.TransactionID = transactionID
+			}
+		}
+		if !step.counts() {
+			switch step.Operation {
+			case constant.OperationPostAuth, constant.OperationVoid:
+				s.data.State = AuthSessionStateOpen
+			case constant.OperationAuth:
+				s.data.State = AuthSessionStateNew
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Refresh queries every unresolved step and every step without a final status and records the result
+// An unresolved step whose transaction does not exist is recorded as closed, since it never took effect
+func (s *AuthSession) Refresh(ctx context.Context, opts ...CallOption) error {
+	s.mu.Lock()
+	appID, merchantID := s.data.AppID, s.data.MerchantID
+	s.mu.Unlock()
+
+	for _, step := range s.Steps() {
+		if !step.Unresolved && lifecycle.IsFinalStatus(step.Status) {
+			continue
+		}
+		q, err := s.client.Query(ctx, &request.QueryRequest{
+			AppID:                appID,
+			MerchantID:           merchantID,
+			TransactionRequestID: step.TransactionRequestID,
+		}, opts...)
+		if err != nil {
+			if step.Unresolved && s.client.IsTransactionNotFound(err) {
+				s.UpdateStatus(step.TransactionRequestID, "", types.TransactionStatusClosed)
+				continue
+			}
+			return err
+		}
+		s.UpdateStatus(step.TransactionRequestID, q.TransactionID, q.TransactionStatus)
+	}
+	return nil
+}
+
+// begin checks that a call is allowed in the session state and marks it in flight
+func (s *AuthSession) begin(operation string, state AuthSessionState) error {
+	if s.inFlight {
+		return errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("%s is not allowed while another auth session call is in flight", operation),
+			"",
+		)
+	}
+	if s.data.State != state {
+		return s.stateError(operation)
+	}
+	for _, step := range s.data.Steps {
+		if step.Unresolved {
+			return errors.NewBusinessError(
+				constant.ErrorCodeParameterError,
+				fmt.Sprintf("%s is not allowed while the outcome of %s %s is unknown, call Refresh first", operation, step.Operation, step.TransactionRequestID),
+				"",
+			)
+		}
+	}
+	s.inFlight = true
+	return nil
+}
+
+// record appends a sent step; err is the error of a call whose outcome is unknown, nil on success
+func (s *AuthSession) record(operation, transactionRequestID, currency string, amount int64, err error) *AuthSessionStep {
+	s.data.Steps = append(s.data.Steps, AuthSessionStep{
+		Operation:            operation,
+		TransactionRequestID: transactionRequestID,
+		Currency:             currency,
+		Amount:               amount,
+		Unresolved:           err != nil,
+		Time:                 time.Now(),
+	})
+	return &s.data.Steps[len(s.data.Steps)-1]
+}
+
+// checkPostAuthAmount verifies that a post authorization total lies within the tolerance
+func (s *AuthSession) checkPostAuthAmount(currency string, amount int64) error {
+	if err := s.checkCurrency(currency); err != nil {
+		return err
+	}
+	authorized := s.totals(currency).Total()
+	if limit := authorized + s.data.Tolerance.allowance(authorized); amount > limit {
+		return errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("post authorization amount %d %s exceeds the authorized total %d %s plus tolerance (max %d)", amount, currency, authorized, currency, limit),
+			"",
+		)
+	}
+	if limit := s.data.Tolerance.minimum(authorized); amount < limit {
+		return errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("post authorization amount %d %s is below the authorized total %d %s minus tolerance (min %d)", amount, currency, authorized, currency, limit),
+			"",
+		)
+	}
+	return nil
+}
+
+// fillOriginal fills the session identifiers into a follow-up request
+func (s *AuthSession) fillOriginal(appID, merchantID, terminalSN, originalTransactionID, originalTransactionRequestID *string) {
+	*appID, *merchantID = s.data.AppID, s.data.MerchantID
+	if *terminalSN == "" {
+		*terminalSN = s.data.TerminalSN
+	}
+	if *originalTransactionID == "" && *originalTransactionRequestID == "" {
+		*originalTransactionID = s.data.TransactionID
+		*originalTransactionRequestID = s.data.TransactionRequestID
+	}
+}
+
+// checkCurrency verifies that a follow-up uses the currency of the initial authorization
+func (s *AuthSession) checkCurrency(currency string) error {
+	for _, step := range s.data.Steps {
+		if step.Operation == constant.OperationAuth && step.Currency != currency {
+			return errors.NewBusinessError(
+				constant.ErrorCodeParameterError,
+				fmt.Sprintf("currency %s differs from the authorization currency %s", currency, step.Currency),
+				"",
+			)
+		}
+	}
+	return nil
+}
+
+func (s *AuthSession) stateError(operation string) error {
+	return errors.NewBusinessError(
+		constant.ErrorCodeParameterError,
+		fmt.Sprintf("%s is not allowed in auth session state %s", operation, s.data.State),
+		"",
+	)
+}
+
+func int64Value(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
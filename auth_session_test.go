@@ -0,0 +1,209 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestAuthSessionLifecycle(t *testing.T) {
+	var postAuth request.PostAuthRequest
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathAuth:
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_AUTH","transactionStatus":"S"}}`))
+		case constant.PathIncrementalAuth:
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_INC","transactionStatus":"S"}}`))
+		case constant.PathPostAuth:
+			_ = json.NewDecoder(r.Body).Decode(&postAuth)
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_CAP","transactionStatus":"P"}}`))
+		}
+	}, Config{})
+
+	ctx := context.Background()
+	session, err := client.NewAuthSession(&AuthSessionConfig{
+		AppID:      "app",
+		MerchantID: "mch",
+		TerminalSN: "T1",
+		Tolerance:  PostAuthTolerance{Percent: 20},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthSession() returned error: %v", err)
+	}
+
+	if _, err := session.Auth(ctx, &request.AuthRequest{
+		TransactionRequestID: "REQ_AUTH",
+		Amount:               &common.AuthAmount{OrderAmount: int64Ptr(10000), PriceCurrency: "USD"},
+	}); err != nil {
+		t.Fatalf("Auth() returned error: %v", err)
+	}
+	if _, err := session.IncrementalAuth(ctx, &request.IncrementalAuthRequest{
+		TransactionRequestID: "REQ_INC",
+		Amount:               &common.AuthAmount{OrderAmount: int64Ptr(5000), PriceCurrency: "USD"},
+	}); err != nil {
+		t.Fatalf("IncrementalAuth() returned error: %v", err)
+	}
+	if _, err := session.IncrementalAuth(ctx, &request.IncrementalAuthRequest{
+		TransactionRequestID: "REQ_EUR",
+		Amount:               &common.AuthAmount{OrderAmount: int64Ptr(5000), PriceCurrency: "EUR"},
+	}); err == nil {
+		t.Fatal("IncrementalAuth() in another currency expected error")
+	}
+
+	if totals := session.Totals("USD"); totals.Authorized != 10000 || totals.Incremented != 5000 || totals.Total() != 15000 {
+		t.Fatalf("Totals() = %+v, want authorized 10000 and incremented 5000", totals)
+	}
+	if limit := session.MaxPostAuthAmount("USD"); limit != 18000 {
+		t.Fatalf("MaxPostAuthAmount() = %d, want 18000", limit)
+	}
+
+	// Serialize and resume, e.g. across a restart
+	data, err := json.Marshal(session)
+	if err != nil {
+		t.Fatalf("Marshal() returned error: %v", err)
+	}
+	session, err = client.ResumeAuthSession(data)
+	if err != nil {
+		t.Fatalf("ResumeAuthSession() returned error: %v", err)
+	}
+
+	tooMuch := &request.PostAuthRequest{
+		TransactionRequestID: "REQ_CAP",
+		Amount:               &common.PostAuthAmount{OrderAmount: int64Ptr(15000), TipAmount: int64Ptr(3001), PriceCurrency: "USD"},
+	}
+	if _, err := session.PostAuth(ctx, tooMuch); err == nil {
+		t.Fatal("PostAuth() above tolerance expected error")
+	}
+	tooMuch.Amount.TipAmount = int64Ptr(3000)
+	if _, err := session.PostAuth(ctx, tooMuch); err != nil {
+		t.Fatalf("PostAuth() returned error: %v", err)
+	}
+	if postAuth.OriginalTransactionID != "TX_AUTH" || postAuth.TerminalSN != "T1" || postAuth.MerchantID != "mch" {
+		t.Fatalf("unexpected post auth request: %+v", postAuth)
+	}
+	if session.State() != AuthSessionStateCaptured || session.Totals("USD").Captured != 18000 {
+		t.Fatalf("State() = %s, Captured = %d; want CAPTURED with 18000", session.State(), session.Totals("USD").Captured)
+	}
+	if _, err := session.Void(ctx, &request.VoidRequest{TransactionRequestID: "REQ_VOID"}); err == nil {
+		t.Fatal("Void() after capture expected error")
+	}
+
+	// A failed capture reopens the session
+	session.UpdateStatus("REQ_CAP", "TX_CAP", "F")
+	if session.State() != AuthSessionStateOpen || session.Totals("USD").Captured != 0 {
+		t.Fatalf("State() = %s after failed capture, want OPEN", session.State())
+	}
+}
+
+func TestAuthSessionUnknownOutcome(t *testing.T) {
+	found := false
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathAuth:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case constant.PathQuery:
+			if !found {
+				_, _ = w.Write([]byte(`{"code":"T404","msg":"transaction not found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_AUTH","transactionRequestId":"REQ_AUTH","transactionStatus":"S"}}`))
+		}
+	}, Config{TransactionNotFoundCodes: []string{"T404"}})
+
+	ctx := context.Background()
+	session, _ := client.NewAuthSession(&AuthSessionConfig{AppID: "app", MerchantID: "mch"})
+	auth := func(id string) error {
+		_, err := session.Auth(ctx, &request.AuthRequest{
+			TransactionRequestID: id,
+			Amount:               &common.AuthAmount{OrderAmount: int64Ptr(10000), PriceCurrency: "USD"},
+		}, WithMaxRetries(0))
+		return err
+	}
+
+	if err := auth("REQ_LOST"); err == nil {
+		t.Fatal("Auth() expected the network error")
+	}
+	if steps := session.Steps(); len(steps) != 1 || !steps[0].Unresolved || session.State() != AuthSessionStateOpen {
+		t.Fatalf("Steps() = %+v, State() = %s; want one unresolved authorization", steps, session.State())
+	}
+	if _, err := session.Void(ctx, &request.VoidRequest{TransactionRequestID: "REQ_VOID"}); err == nil {
+		t.Fatal("Void() with an unresolved authorization expected error")
+	}
+
+	// The lost authorization never reached Nexus
+	if err := session.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() returned error: %v", err)
+	}
+	if session.State() != AuthSessionStateNew || session.Totals("USD").Authorized != 0 {
+		t.Fatalf("State() = %s, Totals() = %+v; want NEW with nothing authorized", session.State(), session.Totals("USD"))
+	}
+
+	// This one did
+	_ = auth("REQ_AUTH")
+	found = true
+	if err := session.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() returned error: %v", err)
+	}
+	if session.State() != AuthSessionStateOpen || session.TransactionID() != "TX_AUTH" || session.Totals("USD").Authorized != 10000 {
+		t.Fatalf("State() = %s, TransactionID() = %s; want OPEN with TX_AUTH", session.State(), session.TransactionID())
+	}
+}
+
+func TestAuthSessionCallInFlight(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_AUTH","transactionStatus":"S"}}`))
+	}, Config{})
+
+	ctx := context.Background()
+	session, _ := client.NewAuthSession(&AuthSessionConfig{AppID: "app", MerchantID: "mch", Tolerance: PostAuthTolerance{MinPercent: 80}})
+	req := func(id string) *request.AuthRequest {
+		return &request.AuthRequest{
+			TransactionRequestID: id,
+			Amount:               &common.AuthAmount{OrderAmount: int64Ptr(10000), PriceCurrency: "USD"},
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := session.Auth(ctx, req("REQ_AUTH"))
+		done <- err
+	}()
+	<-entered
+
+	// Reading the session does not wait for the call
+	if session.State() != AuthSessionStateNew {
+		t.Fatalf("State() = %s during the call, want NEW", session.State())
+	}
+	if _, err := json.Marshal(session); err != nil {
+		t.Fatalf("Marshal() returned error: %v", err)
+	}
+	if _, err := session.Auth(ctx, req("REQ_AGAIN")); err == nil {
+		t.Fatal("second Auth() while the first is in flight expected error")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Auth() returned error: %v", err)
+	}
+
+	if limit := session.MinPostAuthAmount("USD"); limit != 8000 {
+		t.Fatalf("MinPostAuthAmount() = %d, want 8000", limit)
+	}
+	if _, err := session.PostAuth(ctx, &request.PostAuthRequest{
+		TransactionRequestID: "REQ_CAP",
+		Amount:               &common.PostAuthAmount{OrderAmount: int64Ptr(7999), PriceCurrency: "USD"},
+	}); err == nil {
+		t.Fatal("PostAuth() below tolerance expected error")
+	}
+}
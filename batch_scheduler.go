@@ -0,0 +1,473 @@
+package nexus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+)
+
+const (
+	defaultBatchCloseConcurrency   = 8
+	defaultBatchCloseMaxAttempts   = 3
+	defaultBatchCloseRetryInterval = 30 * time.Second
+	defaultBatchClosePollInterval  = time.Minute
+	defaultBatchCloseDescription   = "Scheduled batch close"
+)
+
+// BatchCloseOutcome describes how a scheduled batch close ended
+type BatchCloseOutcome string
+
+const (
+	// BatchCloseOutcomeClosed means BatchClose succeeded
+	BatchCloseOutcomeClosed BatchCloseOutcome = "CLOSED"
+
+	// BatchCloseOutcomeNothingToClose means BatchQuery found no open batch with transactions
+	BatchCloseOutcomeNothingToClose BatchCloseOutcome = "NOTHING_TO_CLOSE"
+
+	// BatchCloseOutcomeFailed means BatchQuery or BatchClose still failed after all attempts
+	BatchCloseOutcomeFailed BatchCloseOutcome = "FAILED"
+)
+
+// String returns the outcome code
+func (o BatchCloseOutcome) String() string {
+	return string(o)
+}
+
+// BatchTerminal is a terminal whose batch is closed daily
+type BatchTerminal struct {
+	// AppID is the application ID
+	AppID string
+
+	// MerchantID is the merchant ID
+	MerchantID string
+
+	// TerminalSN is the terminal serial number (required)
+	TerminalSN string
+
+	// Timezone is the IANA time zone of the cutoff, e.g. "America/New_York" (optional, defaults to UTC)
+	Timezone string
+
+	// Cutoff is the local time of day the batch is closed, "HH:MM" (required)
+	Cutoff string
+
+	// ChannelCode restricts the close to one channel (optional)
+	ChannelCode string
+
+	location *time.Location
+	cutoff   time.Duration
+}
+
+// BatchCloseResult is the summary of one terminal's batch close
+type BatchCloseResult struct {
+	// Terminal is the closed terminal
+	Terminal BatchTerminal
+
+	// BusinessDate is the local date of the cutoff, or of the close for CloseTerminal, "2006-01-02"
+	BusinessDate string
+
+	// Outcome describes how the close ended
+	Outcome BatchCloseOutcome
+
+	// TransactionRequestID is the request ID of the BatchClose call
+	TransactionRequestID string
+
+	// Attempts is the number of attempts made
+	Attempts int
+
+	// OpenBatches are the open batch statistics reported by BatchQuery before closing
+	OpenBatches []common.BatchQueryItem
+
+	// Response is the BatchClose response with the batch totals, nil unless Outcome is BatchCloseOutcomeClosed
+	Response *response.BatchCloseResponse
+
+	// Err is the last error, set when Outcome is BatchCloseOutcomeFailed
+	Err error
+}
+
+// BatchCloseSchedulerConfig holds the configuration for creating a BatchCloseScheduler
+type BatchCloseSchedulerConfig struct {
+	// Client is used for BatchQuery and BatchClose (required)
+	Client *NexusClient
+
+	// OnResult receives the summary of each terminal's close (optional)
+	// It is called from several goroutines at once when Concurrency is above 1
+	OnResult func(ctx context.Context, result *BatchCloseResult)
+
+	// Concurrency is how many terminals are closed at once (optional, defaults to 8)
+	Concurrency int
+
+	// MaxAttempts is how many times a failing terminal is tried (optional, defaults to 3)
+	MaxAttempts int
+
+	// RetryInterval is the delay before the second attempt, doubled after each attempt (optional, defaults to 30s)
+	RetryInterval time.Duration
+
+	// PollInterval is how often Run checks for terminals past their cutoff (optional, defaults to 1m)
+	PollInterval time.Duration
+
+	// CatchUpWindow is how long after a cutoff a close missed on that local day is still made, e.g. when
+	// the scheduler was down across midnight (optional, defaults to 0: a cutoff is only closed on its day)
+	CatchUpWindow time.Duration
+
+	// Description is sent with each BatchClose (optional, defaults to "Scheduled batch close")
+	Description string
+
+	// CallOptions are applied to every call (optional)
+	CallOptions []CallOption
+
+	// Logger is a custom logger implementation (optional, defaults to console logger)
+	Logger Logger
+}
+
+// BatchCloseScheduler closes the batch of each registered terminal once a day after its local cutoff
+// A terminal is checked with BatchQuery first and only closed if it has an open batch with transactions.
+// The BatchClose TransactionRequestID is derived from the terminal and business date, so retries and
+// restarts send the same request for the same day. It is safe for concurrent use
+type BatchCloseScheduler struct {
+	client        *NexusClient
+	onResult      func(ctx context.Context, result *BatchCloseResult)
+	concurrency   int
+	maxAttempts   int
+	retryInterval time.Duration
+	pollInterval  time.Duration
+	catchUp       time.Duration
+	description   string
+	callOptions   []CallOption
+	logger        Logger
+	now           func() time.Time
+
+	mu        sync.Mutex
+	terminals map[string]*BatchTerminal
+	handled   map[string]string // TerminalSN to the last business date closed or found empty
+	closing   map[string]bool   // TerminalSNs CloseDue is closing
+}
+
+// NewBatchCloseScheduler creates a BatchCloseScheduler with the given configuration
+func NewBatchCloseScheduler(config *BatchCloseSchedulerConfig) (*BatchCloseScheduler, error) {
+	if config == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"BatchCloseSchedulerConfig cannot be nil",
+			"",
+		)
+	}
+	if config.Client == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"Client cannot be nil",
+			"",
+		)
+	}
+
+	s := &BatchCloseScheduler{
+		client:        config.Client,
+		onResult:      config.OnResult,
+		concurrency:   config.Concurrency,
+		maxAttempts:   config.MaxAttempts,
+		retryInterval: config.RetryInterval,
+		pollInterval:  config.PollInterval,
+		catchUp:       config.CatchUpWindow,
+		description:   config.Description,
+		callOptions:   config.CallOptions,
+		logger:        config.Logger,
+		now:           time.Now,
+		terminals:     make(map[string]*BatchTerminal),
+		handled:       make(map[string]string),
+		closing:       make(map[string]bool),
+	}
+	if s.concurrency <= 0 {
+		s.concurrency = defaultBatchCloseConcurrency
+	}
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = defaultBatchCloseMaxAttempts
+	}
+	if s.retryInterval <= 0 {
+		s.retryInterval = defaultBatchCloseRetryInterval
+	}
+	if s.pollInterval <= 0 {
+		s.pollInterval = defaultBatchClosePollInterval
+	}
+	if s.description == "" {
+		s.description = defaultBatchCloseDescription
+	}
+	if s.logger == nil {
+		s.logger = http.DefaultLogger()
+	}
+	return s, nil
+}
+
+// Register adds a terminal, replacing one with the same TerminalSN
+// It returns a BusinessError when TerminalSN is empty, Timezone is unknown or Cutoff is not "HH:MM"
+func (s *BatchCloseScheduler) Register(terminal BatchTerminal) error {
+	if terminal.TerminalSN == "" {
+		return errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"TerminalSN cannot be empty",
+			"",
+		)
+	}
+	location := time.UTC
+	if terminal.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(terminal.Timezone); err != nil {
+			return errors.NewBusinessError(
+				constant.ErrorCodeParameterError,
+				fmt.Sprintf("invalid Timezone %q for terminal %s", terminal.Timezone, terminal.TerminalSN),
+				"",
+			)
+		}
+	}
+	cutoff, err := time.Parse("15:04", terminal.Cutoff)
+	if err != nil {
+		return errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("invalid Cutoff %q for terminal %s, want HH:MM", terminal.Cutoff, terminal.TerminalSN),
+			"",
+		)
+	}
+	terminal.location = location
+	terminal.cutoff = time.Duration(cutoff.Hour())*time.Hour + time.Duration(cutoff.Minute())*time.Minute
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.terminals[terminal.TerminalSN] = &terminal
+	return nil
+}
+
+// Unregister removes a terminal
+func (s *BatchCloseScheduler) Unregister(terminalSN string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.terminals, terminalSN)
+	delete(s.handled, terminalSN)
+}
+
+// Terminals returns the registered terminals ordered by TerminalSN
+func (s *BatchCloseScheduler) Terminals() []BatchTerminal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	terminals := make([]BatchTerminal, 0, len(s.terminals))
+	for _, t := range s.terminals {
+		terminals = append(terminals, *t)
+	}
+	sort.Slice(terminals, func(i, j int) bool { return terminals[i].TerminalSN < terminals[j].TerminalSN })
+	return terminals
+}
+
+// Run closes due terminals now and then every PollInterval until ctx is done
+func (s *BatchCloseScheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		s.CloseDue(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// CloseDue closes every terminal whose most recent local cutoff is due and was not handled yet, and
+// returns their results ordered by TerminalSN
+// A cutoff is due for the rest of its local day, and within CatchUpWindow after it. Handled cutoffs are
+// only remembered in memory, so a restart closes a due cutoff again with the same derived
+// TransactionRequestID. A terminal counts as handled only when it was closed or had nothing to close;
+// failed terminals are reported through OnResult and tried again on the next call while still due
+func (s *BatchCloseScheduler) CloseDue(ctx context.Context) []*BatchCloseResult {
+	now := s.now()
+	type due struct {
+		terminal     BatchTerminal
+		businessDate string
+	}
+	var terminals []due
+	s.mu.Lock()
+	for sn, t := range s.terminals {
+		cutoff := lastCutoff(now, t)
+		date := cutoff.Format("2006-01-02")
+		today := date == now.In(t.location).Format("2006-01-02")
+		if (!today && now.Sub(cutoff) > s.catchUp) || s.handled[sn] >= date || s.closing[sn] {
+			continue
+		}
+		s.closing[sn] = true
+		terminals = append(terminals, due{*t, date})
+	}
+	s.mu.Unlock()
+	sort.Slice(terminals, func(i, j int) bool { return terminals[i].terminal.TerminalSN < terminals[j].terminal.TerminalSN })
+
+	results := make([]*BatchCloseResult, len(terminals))
+	sem := make(chan struct{}, s.concurrency)
+	var wg sync.WaitGroup
+	for i, d := range terminals {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, d due) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := s.close(ctx, d.terminal, d.businessDate, BatchCloseRequestID(d.terminal.TerminalSN, d.businessDate))
+			sn := d.terminal.TerminalSN
+
+			s.mu.Lock()
+			delete(s.closing, sn)
+			if _, registered := s.terminals[sn]; registered && result.Outcome != BatchCloseOutcomeFailed && s.handled[sn] < d.businessDate {
+				s.handled[sn] = d.businessDate
+			}
+			s.mu.Unlock()
+			results[i] = result
+		}(i, d)
+	}
+	wg.Wait()
+	return results
+}
+
+// lastCutoff returns the terminal's most recent cutoff at now, in its time zone
+func lastCutoff(now time.Time, t *BatchTerminal) time.Time {
+	local := now.In(t.location)
+	day := local.Day()
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+	if clock < t.cutoff {
+		day--
+	}
+	return time.Date(local.Year(), local.Month(), day, int(t.cutoff/time.Hour), int(t.cutoff%time.Hour/time.Minute), 0, 0, t.location)
+}
+
+// CloseTerminal closes one registered terminal now, outside its schedule
+// The close gets a TransactionRequestID of its own, so it does not stand in for the scheduled close of
+// the day and transactions made after it are still closed at the cutoff
+func (s *BatchCloseScheduler) CloseTerminal(ctx context.Context, terminalSN string) (*BatchCloseResult, error) {
+	s.mu.Lock()
+	t, ok := s.terminals[terminalSN]
+	var terminal BatchTerminal
+	if ok {
+		terminal = *t
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("terminal %s is not registered", terminalSN),
+			"",
+		)
+	}
+	now := s.now().In(terminal.location)
+	requestID := batchCloseRequestID("BCM_", terminal.TerminalSN, now.Format("20060102150405"))
+	return s.close(ctx, terminal, now.Format("2006-01-02"), requestID), nil
+}
+
+// close checks a terminal for an open batch and closes it, then reports the result
+func (s *BatchCloseScheduler) close(ctx context.Context, terminal BatchTerminal, businessDate, requestID string) *BatchCloseResult {
+	result := &BatchCloseResult{
+		Terminal:             terminal,
+		BusinessDate:         businessDate,
+		TransactionRequestID: requestID,
+	}
+	if err := s.closeWithRetry(ctx, terminal, result); err != nil {
+		result.Outcome = BatchCloseOutcomeFailed
+		result.Err = err
+		s.logger.Errorf("Batch close failed - TerminalSN: %s, BusinessDate: %s: %v", terminal.TerminalSN, businessDate, err)
+	} else {
+		s.logger.Infof("Batch close finished - TerminalSN: %s, BusinessDate: %s, Outcome: %s", terminal.TerminalSN, businessDate, result.Outcome)
+	}
+	if s.onResult != nil {
+		s.onResult(ctx, result)
+	}
+	return result
+}
+
+// closeWithRetry runs closeOnce until it succeeds, fails with a business error or runs out of attempts
+func (s *BatchCloseScheduler) closeWithRetry(ctx context.Context, terminal BatchTerminal, result *BatchCloseResult) error {
+	interval := s.retryInterval
+	for {
+		result.Attempts++
+		err := s.closeOnce(ctx, terminal, result)
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(*errors.BusinessError); ok || result.Attempts >= s.maxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		s.logger.Warnf("Batch close failed, retrying in %v - TerminalSN: %s: %v", interval, terminal.TerminalSN, err)
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		interval *= 2
+	}
+}
+
+// closeOnce runs BatchQuery, then BatchClose if there is anything to close
+func (s *BatchCloseScheduler) closeOnce(ctx context.Context, terminal BatchTerminal, result *BatchCloseResult) error {
+	if result.OpenBatches == nil {
+		batches, err := s.client.BatchQuery(ctx, &request.BatchQueryRequest{
+			AppID:      terminal.AppID,
+			MerchantID: terminal.MerchantID,
+			TerminalSN: terminal.TerminalSN,
+		}, s.callOptions...)
+		if err != nil {
+			return err
+		}
+		result.OpenBatches = append([]common.BatchQueryItem{}, batches.BatchList...)
+	}
+
+	open := false
+	for _, item := range result.OpenBatches {
+		if item.TotalCount > 0 && (terminal.ChannelCode == "" || item.ChannelCode == terminal.ChannelCode) {
+			open = true
+		}
+	}
+	if !open {
+		result.Outcome = BatchCloseOutcomeNothingToClose
+		return nil
+	}
+
+	resp, err := s.client.BatchClose(ctx, &request.BatchCloseRequest{
+		AppID:                terminal.AppID,
+		MerchantID:           terminal.MerchantID,
+		TransactionRequestID: result.TransactionRequestID,
+		TerminalSN:           terminal.TerminalSN,
+		Description:          s.description,
+		ChannelCode:          terminal.ChannelCode,
+	}, s.callOptions...)
+	if err != nil {
+		return err
+	}
+	result.Outcome = BatchCloseOutcomeClosed
+	result.Response = resp
+	return nil
+}
+
+// BatchCloseRequestID derives the BatchClose TransactionRequestID of a terminal and business date
+// ("2006-01-02"). IDs that would exceed 64 characters are replaced by a fixed-length hash of the
+// terminal and business date
+func BatchCloseRequestID(terminalSN, businessDate string) string {
+	date := businessDate
+	if t, err := time.Parse("2006-01-02", businessDate); err == nil {
+		date = t.Format("20060102")
+	}
+	return batchCloseRequestID("BC_", terminalSN, date)
+}
+
+// batchCloseRequestID joins prefix, terminal and suffix, hashing the last two when the ID would be too long
+func batchCloseRequestID(prefix, terminalSN, suffix string) string {
+	id := prefix + terminalSN + "_" + suffix
+	if len(id) <= maxTransactionRequestIDLength {
+		return id
+	}
+	sum := sha256.Sum256([]byte(terminalSN + "_" + suffix))
+	return prefix + hex.EncodeToString(sum[:])[:40]
+}
@@ -0,0 +1,170 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+)
+
+func TestBatchCloseSchedulerCloseDue(t *testing.T) {
+	var mu sync.Mutex
+	closes := 0
+	var requestIDs []string
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathBatchQuery:
+			count := "0"
+			if r.URL.Query().Get("terminalSn") == "T1" {
+				count = "3"
+			}
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"batchList":[{"batchNo":"B1","priceCurrency":"USD","totalCount":` + count + `}]}}`))
+		case constant.PathBatchClose:
+			var body struct {
+				TransactionRequestID string `json:"transactionRequestId"`
+				TerminalSN           string `json:"terminalSn"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			closes++
+			first := closes == 1
+			requestIDs = append(requestIDs, body.TransactionRequestID)
+			mu.Unlock()
+			if first {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"batchNo":"B1","terminalSn":"` + body.TerminalSN +
+				`","transactionCount":3,"priceCurrency":"USD","netAmount":4500}}`))
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}, Config{})
+
+	var reported []string
+	scheduler, err := NewBatchCloseScheduler(&BatchCloseSchedulerConfig{
+		Client:        client,
+		RetryInterval: time.Millisecond,
+		CallOptions:   []CallOption{WithMaxRetries(0)},
+		Logger:        nopLogger{},
+		OnResult: func(ctx context.Context, result *BatchCloseResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, result.Terminal.TerminalSN)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewBatchCloseScheduler() returned error: %v", err)
+	}
+	scheduler.now = func() time.Time { return time.Date(2026, 10, 18, 22, 30, 0, 0, time.UTC) }
+
+	for _, terminal := range []BatchTerminal{
+		{TerminalSN: "T1", Cutoff: "22:00"},
+		{TerminalSN: "T2", Timezone: "Asia/Tokyo", Cutoff: "06:00"},
+		{TerminalSN: "T3", Cutoff: "23:00"},
+	} {
+		if err := scheduler.Register(terminal); err != nil {
+			t.Fatalf("Register(%s) returned error: %v", terminal.TerminalSN, err)
+		}
+	}
+	if err := scheduler.Register(BatchTerminal{TerminalSN: "T4", Cutoff: "25:00"}); err == nil {
+		t.Fatal("Register() with an invalid cutoff should return an error")
+	}
+
+	// T3 is before today's cutoff and yesterday's is outside the catch-up window
+	results := scheduler.CloseDue(context.Background())
+	if len(results) != 2 {
+		t.Fatalf("CloseDue() returned %d results, want 2", len(results))
+	}
+	closed, empty := results[0], results[1]
+	if closed.Outcome != BatchCloseOutcomeClosed || closed.Attempts != 2 || closed.BusinessDate != "2026-10-18" ||
+		closed.Response == nil || closed.Response.NetAmount != 4500 {
+		t.Fatalf("T1 result = %+v, want closed after 2 attempts with net 4500", closed)
+	}
+	if got := strings.Join(requestIDs, ","); got != "BC_T1_20261018,BC_T1_20261018" {
+		t.Fatalf("BatchClose request IDs = %s, want the same derived ID on retry", got)
+	}
+	if empty.Terminal.TerminalSN != "T2" || empty.Outcome != BatchCloseOutcomeNothingToClose || empty.BusinessDate != "2026-10-19" {
+		t.Fatalf("T2 result = %+v, want nothing to close on 2026-10-19", empty)
+	}
+	if len(reported) != 2 {
+		t.Fatalf("OnResult called %d times, want 2", len(reported))
+	}
+
+	if again := scheduler.CloseDue(context.Background()); len(again) != 0 {
+		t.Fatalf("second CloseDue() returned %d results, want 0", len(again))
+	}
+
+	// A manual close must not reuse the request ID of a scheduled close
+	manual, err := scheduler.CloseTerminal(context.Background(), "T1")
+	if err != nil {
+		t.Fatalf("CloseTerminal() returned error: %v", err)
+	}
+	if manual.Outcome != BatchCloseOutcomeClosed || manual.TransactionRequestID != "BCM_T1_20261018223000" {
+		t.Fatalf("CloseTerminal() = %+v, want a close with its own request ID", manual)
+	}
+}
+
+func TestBatchCloseSchedulerRetriesFailedTerminal(t *testing.T) {
+	fail := true
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"batchList":[]}}`))
+	}, Config{})
+	scheduler, err := NewBatchCloseScheduler(&BatchCloseSchedulerConfig{
+		Client:        client,
+		MaxAttempts:   1,
+		CatchUpWindow: 12 * time.Hour,
+		CallOptions:   []CallOption{WithMaxRetries(0)},
+		Logger:        nopLogger{},
+	})
+	if err != nil {
+		t.Fatalf("NewBatchCloseScheduler() returned error: %v", err)
+	}
+	now := time.Date(2026, 10, 18, 22, 30, 0, 0, time.UTC)
+	scheduler.now = func() time.Time { return now }
+	if err := scheduler.Register(BatchTerminal{TerminalSN: "T1", Cutoff: "22:00"}); err != nil {
+		t.Fatalf("Register() returned error: %v", err)
+	}
+
+	if results := scheduler.CloseDue(context.Background()); len(results) != 1 || results[0].Outcome != BatchCloseOutcomeFailed {
+		t.Fatalf("CloseDue() = %+v, want one failed result", results)
+	}
+	fail = false
+	now = now.Add(time.Minute)
+	if results := scheduler.CloseDue(context.Background()); len(results) != 1 || results[0].Outcome != BatchCloseOutcomeNothingToClose {
+		t.Fatalf("CloseDue() after the failure = %+v, want the terminal tried again", results)
+	}
+	if results := scheduler.CloseDue(context.Background()); len(results) != 0 {
+		t.Fatalf("CloseDue() after success returned %d results, want 0", len(results))
+	}
+
+	// Two days later, before the cutoff: the missed cutoff of the previous day is within the window
+	now = time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	if results := scheduler.CloseDue(context.Background()); len(results) != 1 || results[0].BusinessDate != "2026-10-19" {
+		t.Fatalf("CloseDue() after downtime = %+v, want a close for 2026-10-19", results)
+	}
+	now = time.Date(2026, 10, 21, 21, 0, 0, 0, time.UTC)
+	if results := scheduler.CloseDue(context.Background()); len(results) != 0 {
+		t.Fatalf("CloseDue() past the catch-up window = %+v, want no close for 2026-10-20", results)
+	}
+}
+
+func TestBatchCloseRequestID(t *testing.T) {
+	long := strings.Repeat("S", 70)
+	got := BatchCloseRequestID(long, "2026-10-18")
+	if len(got) > 64 || got != BatchCloseRequestID(long, "2026-10-18") || got == BatchCloseRequestID(long, "2026-10-19") {
+		t.Fatalf("BatchCloseRequestID() = %q, want a stable ID of at most 64 characters per date", got)
+	}
+	if got := BatchCloseRequestID("T1", strings.Repeat("x", 100)); len(got) > 64 {
+		t.Fatalf("BatchCloseRequestID() with an invalid date = %q, want at most 64 characters", got)
+	}
+}
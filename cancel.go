@@ -0,0 +1,305 @@
+package nexus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/lifecycle"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+// CancelAction is the call Cancel chose to reverse a transaction
+type CancelAction string
+
+const (
+	// CancelActionVoid means the unsettled transaction was voided
+	CancelActionVoid CancelAction = "VOID"
+
+	// CancelActionRefund means the transaction was refunded through the terminal API
+	CancelActionRefund CancelAction = "REFUND"
+
+	// CancelActionOnlineRefund means the checkout transaction was refunded through the checkout API
+	CancelActionOnlineRefund CancelAction = "ONLINE_REFUND"
+)
+
+// String returns the action code
+func (a CancelAction) String() string {
+	return string(a)
+}
+
+// CancelChannel is the channel through which the original transaction was made
+type CancelChannel string
+
+const (
+	// CancelChannelTerminal is a transaction made on a terminal (Sale, Auth, PostAuth, ...)
+	CancelChannelTerminal CancelChannel = "TERMINAL"
+
+	// CancelChannelCheckout is a checkout payment (DirectPayment)
+	CancelChannelCheckout CancelChannel = "CHECKOUT"
+)
+
+// String returns the channel code
+func (c CancelChannel) String() string {
+	return string(c)
+}
+
+// IsValid checks if the channel is valid
+func (c CancelChannel) IsValid() bool {
+	return c == CancelChannelTerminal || c == CancelChannelCheckout
+}
+
+// CancelRequest represents a request to reverse a transaction with whichever call applies
+type CancelRequest struct {
+	// AppID is the application ID
+	AppID string
+
+	// MerchantID is the merchant ID
+	MerchantID string
+
+	// OriginalTransactionID is the SUNBAY Nexus transaction ID to reverse (required)
+	OriginalTransactionID string
+
+	// TransactionRequestID is the request ID of the void or refund, used as the idempotency key (required)
+	TransactionRequestID string
+
+	// Channel is the channel of the original transaction (required); the query result does not report it
+	Channel CancelChannel
+
+	// Amount is the amount to refund (optional, defaults to the whole refundable amount)
+	// A partial amount always results in a refund. Required for a partially refunded transaction
+	// unless Refunds lists its earlier refunds. Authorizations are always voided in full
+	Amount *common.RefundAmount
+
+	// Refunds are the query results of earlier refunds of the transaction (optional), used to compute
+	// the amount still refundable
+	Refunds []*response.QueryResponse
+
+	// TerminalSN is the terminal of the void or refund (optional, defaults to the original transaction's terminal)
+	TerminalSN string
+
+	// Description is the reason of the cancellation
+	Description string
+
+	// NotifyURL is the asynchronous notification URL of the void or refund
+	NotifyURL string
+}
+
+// CancelResult describes what Cancel did
+type CancelResult struct {
+	// Action is the call that was made
+	Action CancelAction
+
+	// Original is the query result of the original transaction before the cancellation
+	Original *response.QueryResponse
+
+	// TransactionID is the SUNBAY Nexus transaction ID of the void or refund
+	TransactionID string
+
+	// TransactionRequestID is the request ID of the void or refund
+	TransactionRequestID string
+
+	// TransactionStatus is the status of the void or refund
+	TransactionStatus types.TransactionStatus
+
+	// Amount is the refunded amount, nil for voids which reverse the whole transaction
+	Amount *common.RefundAmount
+
+	// Void is the void response when Action is CancelActionVoid
+	Void *response.VoidResponse
+
+	// Refund is the refund response when Action is CancelActionRefund
+	Refund *response.RefundResponse
+
+	// OnlineRefund is the online refund response when Action is CancelActionOnlineRefund
+	OnlineRefund *response.OnlineRefundResponse
+}
+
+// Cancel reverses a transaction, choosing the call from its channel and query result
+// Checkout payments are refunded with OnlineRefund. Unsettled authorizations (TransactionBatchStatus U)
+// are voided. Other terminal transactions are voided while their batch is unsettled and the whole
+// amount is cancelled, and refunded otherwise. Refund amounts are checked locally with
+// CalculateRefundEligibility first
+func (c *NexusClient) Cancel(ctx context.Context, req *CancelRequest, opts ...CallOption) (*CancelResult, error) {
+	if req == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"CancelRequest cannot be nil",
+			"",
+		)
+	}
+	if req.OriginalTransactionID == "" || req.TransactionRequestID == "" {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"OriginalTransactionID and TransactionRequestID cannot be empty",
+			"",
+		)
+	}
+	if !req.Channel.IsValid() {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("Channel must be %s or %s, got %q", CancelChannelTerminal, CancelChannelCheckout, req.Channel),
+			"",
+		)
+	}
+
+	original, err := c.Query(ctx, &request.QueryRequest{
+		AppID:         req.AppID,
+		MerchantID:    req.MerchantID,
+		TransactionID: req.OriginalTransactionID,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CancelResult{
+		Original:             original,
+		TransactionRequestID: req.TransactionRequestID,
+	}
+	terminalSN := req.TerminalSN
+	if terminalSN == "" {
+		terminalSN = original.TerminalSN
+	}
+
+	if req.Channel == CancelChannelTerminal && isAuthorizationType(original.TransactionType) {
+		// Authorizations are not refundable; only the hold of an unsettled one can be released
+		if req.Amount != nil {
+			return nil, errors.NewBusinessError(
+				constant.ErrorCodeParameterError,
+				fmt.Sprintf("authorization %s is voided in full, Amount must not be set", req.OriginalTransactionID),
+				"",
+			)
+		}
+		if original.TransactionBatchStatus != types.TransactionBatchStatusU || !lifecycle.CanVoid(original) {
+			return nil, errors.NewBusinessError(
+				constant.ErrorCodeParameterError,
+				fmt.Sprintf("authorization %s cannot be voided in state %s, batch %s",
+					req.OriginalTransactionID, lifecycle.StateOf(original), original.TransactionBatchStatus),
+				"",
+			)
+		}
+		return c.cancelByVoid(ctx, req, terminalSN, result, opts)
+	}
+
+	if original.RelatedTransactionStatus == types.RelatedTransactionStatusPartRefunded && req.Amount == nil && len(req.Refunds) == 0 {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("transaction %s is partially refunded, Amount or Refunds is required", req.OriginalTransactionID),
+			"",
+		)
+	}
+	eligibility, err := CalculateRefundEligibility(original, req.Refunds)
+	if err != nil {
+		return nil, err
+	}
+	if !eligibility.Eligible {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("transaction %s cannot be cancelled: %s", req.OriginalTransactionID, eligibility.Reason),
+			"",
+		)
+	}
+	amount := eligibility.RefundAmount()
+	if req.Amount != nil {
+		requested := *req.Amount
+		amount = &requested
+	}
+	if err := eligibility.Check(amount); err != nil {
+		return nil, err
+	}
+	fullAmount := refundBreakdown(amount) == eligibility.Original
+
+	switch {
+	case req.Channel == CancelChannelCheckout:
+		resp, err := c.OnlineRefund(ctx, &request.OnlineRefundRequest{
+			AppID:                 req.AppID,
+			MerchantID:            req.MerchantID,
+			TransactionRequestID:  req.TransactionRequestID,
+			OriginalTransactionID: req.OriginalTransactionID,
+			Amount: &common.OnlineRefundAmount{
+				PriceCurrency:   eligibility.Currency,
+				OrderAmount:     amount.OrderAmount,
+				TipAmount:       amount.TipAmount,
+				TaxAmount:       amount.TaxAmount,
+				SurchargeAmount: amount.SurchargeAmount,
+			},
+			Description: req.Description,
+			NotifyURL:   req.NotifyURL,
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		result.Action = CancelActionOnlineRefund
+		result.OnlineRefund = resp
+		result.TransactionID = resp.TransactionID
+		result.TransactionStatus = types.TransactionStatus(resp.TransactionStatus)
+		result.Amount = amount
+
+	case original.TransactionBatchStatus == types.TransactionBatchStatusU && fullAmount && lifecycle.CanVoid(original):
+		return c.cancelByVoid(ctx, req, terminalSN, result, opts)
+
+	default:
+		if amount.PriceCurrency == "" {
+			amount.PriceCurrency = eligibility.Currency
+		}
+		resp, err := c.Refund(ctx, &request.RefundRequest{
+			AppID:                 req.AppID,
+			MerchantID:            req.MerchantID,
+			OriginalTransactionID: req.OriginalTransactionID,
+			TransactionRequestID:  req.TransactionRequestID,
+			Amount:                amount,
+			Description:           req.Description,
+			TerminalSN:            terminalSN,
+			NotifyURL:             req.NotifyURL,
+		}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		result.Action = CancelActionRefund
+		result.Refund = resp
+		result.TransactionID = resp.TransactionID
+		result.TransactionStatus = types.TransactionStatus(resp.TransactionStatus)
+		result.Amount = amount
+	}
+	return result, nil
+}
+
+// cancelByVoid voids the original transaction and fills result
+func (c *NexusClient) cancelByVoid(ctx context.Context, req *CancelRequest, terminalSN string, result *CancelResult, opts []CallOption) (*CancelResult, error) {
+	resp, err := c.Void(ctx, &request.VoidRequest{
+		AppID:                 req.AppID,
+		MerchantID:            req.MerchantID,
+		OriginalTransactionID: req.OriginalTransactionID,
+		TransactionRequestID:  req.TransactionRequestID,
+		Description:           req.Description,
+		TerminalSN:            terminalSN,
+		NotifyURL:             req.NotifyURL,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result.Action = CancelActionVoid
+	result.Void = resp
+	result.TransactionID = resp.TransactionID
+	result.TransactionStatus = types.TransactionStatus(resp.TransactionStatus)
+	return result, nil
+}
+
+// isAuthorizationType reports whether a transaction type only holds funds
+func isAuthorizationType(t types.TransactionType) bool {
+	return t == types.TransactionTypeAuth || t == types.TransactionTypeIncremental
+}
+
+// refundBreakdown splits a refund amount into its parts
+func refundBreakdown(a *common.RefundAmount) AmountBreakdown {
+	return AmountBreakdown{
+		Order:     int64Value(a.OrderAmount),
+		Tip:       int64Value(a.TipAmount),
+		Tax:       int64Value(a.TaxAmount),
+		Surcharge: int64Value(a.SurchargeAmount),
+	}
+}
@@ -0,0 +1,131 @@
+package nexus
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+func TestCancelChoosesAction(t *testing.T) {
+	const success = `{"code":"0","msg":"success","data":{"transactionId":"TX_2","transactionStatus":"P"}}`
+	cases := []struct {
+		name     string
+		original string
+		channel  CancelChannel
+		amount   *common.RefundAmount
+		want     CancelAction
+		path     string
+	}{
+		{"unsettled full amount", `"transactionType":"SALE","terminalSn":"T1","transactionBatchStatus":"U"`, CancelChannelTerminal, nil, CancelActionVoid, constant.PathVoid},
+		{"unsettled partial amount", `"transactionType":"SALE","terminalSn":"T1","transactionBatchStatus":"U"`, CancelChannelTerminal, &common.RefundAmount{OrderAmount: int64Ptr(400)}, CancelActionRefund, constant.PathRefund},
+		{"settled", `"transactionType":"SALE","terminalSn":"T1","transactionBatchStatus":"C"`, CancelChannelTerminal, nil, CancelActionRefund, constant.PathRefund},
+		{"unsettled authorization", `"transactionType":"AUTH","terminalSn":"T1","transactionBatchStatus":"U"`, CancelChannelTerminal, nil, CancelActionVoid, constant.PathVoid},
+		{"incremental authorization", `"transactionType":"AUTH","terminalSn":"T1","transactionBatchStatus":"U","relatedTransactionStatus":"INCREMENTAL"`, CancelChannelTerminal, nil, CancelActionVoid, constant.PathVoid},
+		{"partially refunded with amount", `"transactionType":"SALE","terminalSn":"T1","transactionBatchStatus":"U","relatedTransactionStatus":"PART_REFUNDED"`, CancelChannelTerminal, &common.RefundAmount{OrderAmount: int64Ptr(400)}, CancelActionRefund, constant.PathRefund},
+		{"checkout", `"transactionType":"SALE","terminalSn":"T1","transactionBatchStatus":"U"`, CancelChannelCheckout, nil, CancelActionOnlineRefund, constant.PathCheckoutRefund},
+	}
+
+	for _, tc := range cases {
+		var called string
+		client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == constant.PathQuery {
+				_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionStatus":"S",` +
+					`"amount":{"priceCurrency":"USD","orderAmount":1000,"tipAmount":100},` + tc.original + `}}`))
+				return
+			}
+			called = r.URL.Path
+			_, _ = w.Write([]byte(success))
+		}, Config{})
+
+		result, err := client.Cancel(context.Background(), &CancelRequest{
+			AppID:                 "app",
+			MerchantID:            "mch",
+			OriginalTransactionID: "TX_1",
+			TransactionRequestID:  "CANCEL_1",
+			Channel:               tc.channel,
+			Amount:                tc.amount,
+		})
+		if err != nil {
+			t.Fatalf("%s: Cancel() returned error: %v", tc.name, err)
+		}
+		if result.Action != tc.want || called != tc.path || result.TransactionID != "TX_2" {
+			t.Fatalf("%s: Action = %s via %s, want %s via %s", tc.name, result.Action, called, tc.want, tc.path)
+		}
+	}
+}
+
+func TestCancelRejectsOverRefund(t *testing.T) {
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != constant.PathQuery {
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionType":"SALE","transactionStatus":"S",` +
+			`"terminalSn":"T1","transactionBatchStatus":"C","amount":{"priceCurrency":"USD","orderAmount":1000}}}`))
+	}, Config{})
+
+	_, err := client.Cancel(context.Background(), &CancelRequest{
+		OriginalTransactionID: "TX_1",
+		TransactionRequestID:  "CANCEL_1",
+		Channel:               CancelChannelTerminal,
+		Amount:                &common.RefundAmount{OrderAmount: int64Ptr(1001)},
+	})
+	if _, ok := err.(*errors.OverRefundError); !ok {
+		t.Fatalf("Cancel() error = %v, want OverRefundError", err)
+	}
+}
+
+func TestCancelPartiallyRefundedUsesKnownRefunds(t *testing.T) {
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != constant.PathQuery {
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionType":"SALE","transactionStatus":"S",` +
+			`"terminalSn":"T1","transactionBatchStatus":"C","relatedTransactionStatus":"PART_REFUNDED","amount":{"priceCurrency":"USD","orderAmount":1000}}}`))
+	}, Config{})
+
+	req := &CancelRequest{
+		OriginalTransactionID: "TX_1",
+		TransactionRequestID:  "CANCEL_1",
+		Channel:               CancelChannelTerminal,
+	}
+	_, err := client.Cancel(context.Background(), req)
+	if bizErr, ok := err.(*errors.BusinessError); !ok || bizErr.Code() != constant.ErrorCodeParameterError {
+		t.Fatalf("Cancel() without Amount or Refunds error = %v, want parameter error", err)
+	}
+
+	req.Refunds = []*response.QueryResponse{{
+		TransactionID:     "TX_R1",
+		TransactionStatus: types.TransactionStatusSuccess,
+		Amount:            &common.Amount{PriceCurrency: "USD", OrderAmount: int64Ptr(300)},
+	}}
+	req.Amount = &common.RefundAmount{OrderAmount: int64Ptr(800)}
+	_, err = client.Cancel(context.Background(), req)
+	if overErr, ok := err.(*errors.OverRefundError); !ok || overErr.Refundable() != 700 {
+		t.Fatalf("Cancel() error = %v, want OverRefundError with 700 refundable", err)
+	}
+}
+
+func TestCancelRejectsCapturedAuthorization(t *testing.T) {
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != constant.PathQuery {
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionType":"AUTH","transactionStatus":"S",` +
+			`"terminalSn":"T1","transactionBatchStatus":"U","relatedTransactionStatus":"CAPTURE","amount":{"priceCurrency":"USD","orderAmount":1000}}}`))
+	}, Config{})
+
+	_, err := client.Cancel(context.Background(), &CancelRequest{
+		OriginalTransactionID: "TX_1",
+		TransactionRequestID:  "CANCEL_1",
+		Channel:               CancelChannelTerminal,
+	})
+	if _, ok := err.(*errors.BusinessError); !ok {
+		t.Fatalf("Cancel() error = %v, want BusinessError", err)
+	}
+}
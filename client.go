@@ -2,13 +2,16 @@ package nexus
 
 import (
 	"context"
+	nethttp "net/http"
 	"time"
 
 	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
 	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
 	"github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/journal"
 	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
 	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/ratelimit"
 )
 
 const (
@@ -37,7 +40,11 @@ type Logger interface {
 // NexusClient is the main client for Sunbay Nexus SDK
 // The client is thread-safe and can be safely used by multiple goroutines
 type NexusClient struct {
-	httpClient *http.Client
+	httpClient      *http.Client
+	idempotentRetry bool
+	notFoundCodes   []string
+	journal         journal.Store
+	logger          Logger
 }
 
 // Config holds the configuration for creating a NexusClient
@@ -51,12 +58,28 @@ type Config struct {
 	// ConnectTimeout is the connection timeout (optional, defaults to 30s)
 	ConnectTimeout time.Duration
 
-	// ReadTimeout is the read timeout (optional, defaults to 60s)
+	// ReadTimeout is the timeout of each request attempt, from sending the request to reading the whole response (optional, defaults to 60s)
 	ReadTimeout time.Duration
 
-	// MaxRetries is the maximum number of retries for GET requests (optional, defaults to 3)
+	// TLSHandshakeTimeout is the TLS handshake timeout (optional, defaults to 10s)
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout is the time to wait for response headers after the request is written (optional, no limit by default)
+	// Keep it above the longest expected cardholder interaction when used with terminal transactions
+	ResponseHeaderTimeout time.Duration
+
+	// OperationTimeouts overrides ReadTimeout per operation, keyed by constant.Operation* (optional)
+	// For example, a short timeout for constant.OperationQuery and a long one for constant.OperationSale
+	OperationTimeouts map[string]time.Duration
+
+	// MaxRetries is the maximum number of retries after the initial attempt (optional, defaults to 3)
+	// Used by the default retry policy; ignored when RetryPolicy is set
 	MaxRetries int
 
+	// RetryPolicy decides which failed attempts are retried and how long to wait between them
+	// (optional, defaults to exponential backoff with full jitter that honors Retry-After)
+	RetryPolicy http.RetryPolicy
+
 	// MaxTotal is the maximum total connections in the connection pool (optional, defaults to 200)
 	MaxTotal int
 
@@ -65,6 +88,45 @@ type Config struct {
 
 	// Logger is a custom logger implementation (optional, defaults to console logger)
 	Logger Logger
+
+	// HTTPClient is a caller-supplied HTTP client, e.g. routed through an egress proxy or using mTLS (optional)
+	// The SDK still applies authentication headers, timeouts and retries on top of it.
+	// ConnectTimeout, TLSHandshakeTimeout, ResponseHeaderTimeout, MaxTotal and MaxPerRoute are not applied to it.
+	// Takes precedence over Transport
+	HTTPClient *nethttp.Client
+
+	// Transport is a caller-supplied http.RoundTripper used instead of the SDK-built transport (optional)
+	// ConnectTimeout, TLSHandshakeTimeout, ResponseHeaderTimeout, MaxTotal and MaxPerRoute are not applied to it
+	Transport nethttp.RoundTripper
+
+	// CircuitBreaker enables a circuit breaker per API path (optional, disabled when nil)
+	// While a path's circuit is open, calls fail immediately with errors.CircuitOpenError
+	CircuitBreaker *http.CircuitBreakerConfig
+
+	// RateLimiter limits requests on the client side globally, per MerchantID and per TerminalSN (optional)
+	// Every attempt, retries included, blocks until allowed or until ctx is done. Share one limiter between clients to apply a common budget
+	RateLimiter *ratelimit.Limiter
+
+	// Middlewares are applied around every API call, the first one being outermost (optional)
+	Middlewares []http.Middleware
+
+	// IdempotentRetry enables automatic retry of transaction POST requests that carry a TransactionRequestID
+	// (optional, defaults to false). Abort, TipAdjust, BatchClose and CreateCheckoutSession are never retried.
+	// Retried POSTs are sent with the identical body and TransactionRequestID, which the API uses as the idempotency key.
+	// When retries are exhausted with a network error, the SDK queries the transaction by TransactionRequestID
+	// and returns its actual outcome instead of an ambiguous NetworkError
+	IdempotentRetry bool
+
+	// TransactionNotFoundCodes are the business error codes with which Query reports that a transaction
+	// does not exist (optional). Idempotent retry, journal recovery, auth session refresh, saga rollback and
+	// reconciliation use them to tell a transaction that was never created from a failed lookup. Without
+	// them no query error is taken as proof that a transaction does not exist
+	TransactionNotFoundCodes []string
+
+	// Journal records every POST request before it is sent and its outcome afterwards (optional, disabled when nil)
+	// If the journal cannot be written, the request is not sent. Call Recover on startup to resolve the
+	// requests a crash left unfinished. See journal.NewFileStore for the file-based store
+	Journal journal.Store
 }
 
 // NewNexusClient creates a new NexusClient with the given configuration
@@ -128,14 +190,50 @@ func NewNexusClient(config *Config) (*NexusClient, error) {
 		maxPerRoute,
 		config.Logger,
 	)
+	httpClientWrapper.SetTransportTimeouts(config.TLSHandshakeTimeout, config.ResponseHeaderTimeout)
+	if config.HTTPClient != nil {
+		httpClientWrapper.SetHTTPClient(config.HTTPClient)
+	} else if config.Transport != nil {
+		httpClientWrapper.SetTransport(config.Transport)
+	}
+	httpClientWrapper.SetOperationTimeouts(config.OperationTimeouts)
+	httpClientWrapper.SetRetryPolicy(config.RetryPolicy)
+	if config.RateLimiter != nil {
+		httpClientWrapper.SetAttemptGate(rateLimitGate(config.RateLimiter))
+	}
+	httpClientWrapper.Use(config.Middlewares...)
+	if config.CircuitBreaker != nil {
+		httpClientWrapper.SetCircuitBreaker(http.NewCircuitBreaker(*config.CircuitBreaker))
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = http.DefaultLogger()
+	}
 
 	return &NexusClient{
-		httpClient: httpClientWrapper,
+		httpClient:      httpClientWrapper,
+		idempotentRetry: config.IdempotentRetry,
+		notFoundCodes:   append([]string(nil), config.TransactionNotFoundCodes...),
+		journal:         config.Journal,
+		logger:          logger,
 	}, nil
 }
 
+// IsTransactionNotFound reports whether err is a Query error with one of Config.TransactionNotFoundCodes
+// Other business errors, e.g. rejected credentials or throttling, say nothing about whether the transaction exists
+func (c *NexusClient) IsTransactionNotFound(err error) bool {
+	return errors.HasCode(err, c.notFoundCodes...)
+}
+
+// Use appends middleware to the chain applied around every API call
+// Middleware added first is outermost. Use must be called before the client is shared between goroutines
+func (c *NexusClient) Use(middleware ...http.Middleware) {
+	c.httpClient.Use(middleware...)
+}
+
 // Sale executes a sale transaction
-func (c *NexusClient) Sale(ctx context.Context, req *request.SaleRequest) (*response.SaleResponse, error) {
+func (c *NexusClient) Sale(ctx context.Context, req *request.SaleRequest, opts ...CallOption) (*response.SaleResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -145,7 +243,7 @@ func (c *NexusClient) Sale(ctx context.Context, req *request.SaleRequest) (*resp
 	}
 
 	resp := &response.SaleResponse{}
-	err := c.httpClient.Post(constant.PathSale, req, resp)
+	err := c.postTransaction(ctx, constant.PathSale, req, resp, req.AppID, req.MerchantID, req.TransactionRequestID, requestOptions(constant.OperationSale, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +251,7 @@ func (c *NexusClient) Sale(ctx context.Context, req *request.SaleRequest) (*resp
 }
 
 // Auth executes an authorization (pre-auth) transaction
-func (c *NexusClient) Auth(ctx context.Context, req *request.AuthRequest) (*response.AuthResponse, error) {
+func (c *NexusClient) Auth(ctx context.Context, req *request.AuthRequest, opts ...CallOption) (*response.AuthResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -163,7 +261,7 @@ func (c *NexusClient) Auth(ctx context.Context, req *request.AuthRequest) (*resp
 	}
 
 	resp := &response.AuthResponse{}
-	err := c.httpClient.Post(constant.PathAuth, req, resp)
+	err := c.postTransaction(ctx, constant.PathAuth, req, resp, req.AppID, req.MerchantID, req.TransactionRequestID, requestOptions(constant.OperationAuth, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -171,7 +269,7 @@ func (c *NexusClient) Auth(ctx context.Context, req *request.AuthRequest) (*resp
 }
 
 // ForcedAuth executes a forced authorization transaction
-func (c *NexusClient) ForcedAuth(ctx context.Context, req *request.ForcedAuthRequest) (*response.ForcedAuthResponse, error) {
+func (c *NexusClient) ForcedAuth(ctx context.Context, req *request.ForcedAuthRequest, opts ...CallOption) (*response.ForcedAuthResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -181,7 +279,7 @@ func (c *NexusClient) ForcedAuth(ctx context.Context, req *request.ForcedAuthReq
 	}
 
 	resp := &response.ForcedAuthResponse{}
-	err := c.httpClient.Post(constant.PathForcedAuth, req, resp)
+	err := c.postTransaction(ctx, constant.PathForcedAuth, req, resp, req.AppID, req.MerchantID, req.TransactionRequestID, requestOptions(constant.OperationForcedAuth, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -189,7 +287,7 @@ func (c *NexusClient) ForcedAuth(ctx context.Context, req *request.ForcedAuthReq
 }
 
 // IncrementalAuth executes an incremental authorization transaction
-func (c *NexusClient) IncrementalAuth(ctx context.Context, req *request.IncrementalAuthRequest) (*response.IncrementalAuthResponse, error) {
+func (c *NexusClient) IncrementalAuth(ctx context.Context, req *request.IncrementalAuthRequest, opts ...CallOption) (*response.IncrementalAuthResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -199,7 +297,7 @@ func (c *NexusClient) IncrementalAuth(ctx context.Context, req *request.Incremen
 	}
 
 	resp := &response.IncrementalAuthResponse{}
-	err := c.httpClient.Post(constant.PathIncrementalAuth, req, resp)
+	err := c.postTransaction(ctx, constant.PathIncrementalAuth, req, resp, req.AppID, req.MerchantID, req.TransactionRequestID, requestOptions(constant.OperationIncrementalAuth, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -207,7 +305,7 @@ func (c *NexusClient) IncrementalAuth(ctx context.Context, req *request.Incremen
 }
 
 // PostAuth executes a post authorization (pre-auth completion) transaction
-func (c *NexusClient) PostAuth(ctx context.Context, req *request.PostAuthRequest) (*response.PostAuthResponse, error) {
+func (c *NexusClient) PostAuth(ctx context.Context, req *request.PostAuthRequest, opts ...CallOption) (*response.PostAuthResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -217,7 +315,7 @@ func (c *NexusClient) PostAuth(ctx context.Context, req *request.PostAuthRequest
 	}
 
 	resp := &response.PostAuthResponse{}
-	err := c.httpClient.Post(constant.PathPostAuth, req, resp)
+	err := c.postTransaction(ctx, constant.PathPostAuth, req, resp, req.AppID, req.MerchantID, req.TransactionRequestID, requestOptions(constant.OperationPostAuth, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +323,7 @@ func (c *NexusClient) PostAuth(ctx context.Context, req *request.PostAuthRequest
 }
 
 // Refund executes a refund transaction
-func (c *NexusClient) Refund(ctx context.Context, req *request.RefundRequest) (*response.RefundResponse, error) {
+func (c *NexusClient) Refund(ctx context.Context, req *request.RefundRequest, opts ...CallOption) (*response.RefundResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -235,7 +333,7 @@ func (c *NexusClient) Refund(ctx context.Context, req *request.RefundRequest) (*
 	}
 
 	resp := &response.RefundResponse{}
-	err := c.httpClient.Post(constant.PathRefund, req, resp)
+	err := c.postTransaction(ctx, constant.PathRefund, req, resp, req.AppID, req.MerchantID, req.TransactionRequestID, requestOptions(constant.OperationRefund, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -243,7 +341,7 @@ func (c *NexusClient) Refund(ctx context.Context, req *request.RefundRequest) (*
 }
 
 // Void executes a void transaction
-func (c *NexusClient) Void(ctx context.Context, req *request.VoidRequest) (*response.VoidResponse, error) {
+func (c *NexusClient) Void(ctx context.Context, req *request.VoidRequest, opts ...CallOption) (*response.VoidResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -253,7 +351,7 @@ func (c *NexusClient) Void(ctx context.Context, req *request.VoidRequest) (*resp
 	}
 
 	resp := &response.VoidResponse{}
-	err := c.httpClient.Post(constant.PathVoid, req, resp)
+	err := c.postTransaction(ctx, constant.PathVoid, req, resp, req.AppID, req.MerchantID, req.TransactionRequestID, requestOptions(constant.OperationVoid, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -261,7 +359,7 @@ func (c *NexusClient) Void(ctx context.Context, req *request.VoidRequest) (*resp
 }
 
 // Abort executes an abort transaction
-func (c *NexusClient) Abort(ctx context.Context, req *request.AbortRequest) (*response.AbortResponse, error) {
+func (c *NexusClient) Abort(ctx context.Context, req *request.AbortRequest, opts ...CallOption) (*response.AbortResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -271,7 +369,7 @@ func (c *NexusClient) Abort(ctx context.Context, req *request.AbortRequest) (*re
 	}
 
 	resp := &response.AbortResponse{}
-	err := c.httpClient.Post(constant.PathAbort, req, resp)
+	err := c.post(ctx, constant.PathAbort, req, resp, requestOptions(constant.OperationAbort, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -279,7 +377,7 @@ func (c *NexusClient) Abort(ctx context.Context, req *request.AbortRequest) (*re
 }
 
 // TipAdjust executes a tip adjust transaction
-func (c *NexusClient) TipAdjust(ctx context.Context, req *request.TipAdjustRequest) (*response.TipAdjustResponse, error) {
+func (c *NexusClient) TipAdjust(ctx context.Context, req *request.TipAdjustRequest, opts ...CallOption) (*response.TipAdjustResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -289,7 +387,7 @@ func (c *NexusClient) TipAdjust(ctx context.Context, req *request.TipAdjustReque
 	}
 
 	resp := &response.TipAdjustResponse{}
-	err := c.httpClient.Post(constant.PathTipAdjust, req, resp)
+	err := c.post(ctx, constant.PathTipAdjust, req, resp, requestOptions(constant.OperationTipAdjust, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -297,7 +395,7 @@ func (c *NexusClient) TipAdjust(ctx context.Context, req *request.TipAdjustReque
 }
 
 // Query queries a transaction
-func (c *NexusClient) Query(ctx context.Context, req *request.QueryRequest) (*response.QueryResponse, error) {
+func (c *NexusClient) Query(ctx context.Context, req *request.QueryRequest, opts ...CallOption) (*response.QueryResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -307,7 +405,7 @@ func (c *NexusClient) Query(ctx context.Context, req *request.QueryRequest) (*re
 	}
 
 	resp := &response.QueryResponse{}
-	err := c.httpClient.Get(constant.PathQuery, req, resp)
+	err := c.httpClient.Get(ctx, constant.PathQuery, req, resp, requestOptions(constant.OperationQuery, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -315,7 +413,7 @@ func (c *NexusClient) Query(ctx context.Context, req *request.QueryRequest) (*re
 }
 
 // BatchClose executes a batch close transaction
-func (c *NexusClient) BatchClose(ctx context.Context, req *request.BatchCloseRequest) (*response.BatchCloseResponse, error) {
+func (c *NexusClient) BatchClose(ctx context.Context, req *request.BatchCloseRequest, opts ...CallOption) (*response.BatchCloseResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -325,7 +423,7 @@ func (c *NexusClient) BatchClose(ctx context.Context, req *request.BatchCloseReq
 	}
 
 	resp := &response.BatchCloseResponse{}
-	err := c.httpClient.Post(constant.PathBatchClose, req, resp)
+	err := c.post(ctx, constant.PathBatchClose, req, resp, requestOptions(constant.OperationBatchClose, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -333,7 +431,7 @@ func (c *NexusClient) BatchClose(ctx context.Context, req *request.BatchCloseReq
 }
 
 // BatchQuery queries batch statistics
-func (c *NexusClient) BatchQuery(ctx context.Context, req *request.BatchQueryRequest) (*response.BatchQueryResponse, error) {
+func (c *NexusClient) BatchQuery(ctx context.Context, req *request.BatchQueryRequest, opts ...CallOption) (*response.BatchQueryResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -343,7 +441,7 @@ func (c *NexusClient) BatchQuery(ctx context.Context, req *request.BatchQueryReq
 	}
 
 	resp := &response.BatchQueryResponse{}
-	err := c.httpClient.Get(constant.PathBatchQuery, req, resp)
+	err := c.httpClient.Get(ctx, constant.PathBatchQuery, req, resp, requestOptions(constant.OperationBatchQuery, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -352,7 +450,7 @@ func (c *NexusClient) BatchQuery(ctx context.Context, req *request.BatchQueryReq
 
 // CreateCheckoutSession creates a hosted payment page session (POST /v1/checkout/create-session).
 // See https://docs.sunbay.dev/en/refspec/online/checkout/checkout-api-integration
-func (c *NexusClient) CreateCheckoutSession(ctx context.Context, req *request.CreateCheckoutSessionRequest) (*response.CreateCheckoutSessionResponse, error) {
+func (c *NexusClient) CreateCheckoutSession(ctx context.Context, req *request.CreateCheckoutSessionRequest, opts ...CallOption) (*response.CreateCheckoutSessionResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -362,7 +460,7 @@ func (c *NexusClient) CreateCheckoutSession(ctx context.Context, req *request.Cr
 	}
 
 	resp := &response.CreateCheckoutSessionResponse{}
-	err := c.httpClient.Post(constant.PathCheckoutCreateSession, req, resp)
+	err := c.post(ctx, constant.PathCheckoutCreateSession, req, resp, requestOptions(constant.OperationCreateCheckoutSession, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -371,7 +469,7 @@ func (c *NexusClient) CreateCheckoutSession(ctx context.Context, req *request.Cr
 
 // DirectPayment initiates an online wallet payment without a hosted session (POST /v1/checkout/sale).
 // See https://docs.sunbay.dev/en/refspec/online/direct-payment
-func (c *NexusClient) DirectPayment(ctx context.Context, req *request.CheckoutDirectSaleRequest) (*response.CheckoutDirectSaleResponse, error) {
+func (c *NexusClient) DirectPayment(ctx context.Context, req *request.CheckoutDirectSaleRequest, opts ...CallOption) (*response.CheckoutDirectSaleResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -381,7 +479,7 @@ func (c *NexusClient) DirectPayment(ctx context.Context, req *request.CheckoutDi
 	}
 
 	resp := &response.CheckoutDirectSaleResponse{}
-	err := c.httpClient.Post(constant.PathCheckoutSale, req, resp)
+	err := c.postTransaction(ctx, constant.PathCheckoutSale, req, resp, req.AppID, req.MerchantID, req.TransactionRequestID, requestOptions(constant.OperationDirectPayment, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -391,7 +489,7 @@ func (c *NexusClient) DirectPayment(ctx context.Context, req *request.CheckoutDi
 // OnlineRefund executes an online refund (POST /v1/checkout/refund).
 // Either OriginalTransactionID or OriginalTransactionRequestID must be provided
 // in the request to identify the original transaction to refund.
-func (c *NexusClient) OnlineRefund(ctx context.Context, req *request.OnlineRefundRequest) (*response.OnlineRefundResponse, error) {
+func (c *NexusClient) OnlineRefund(ctx context.Context, req *request.OnlineRefundRequest, opts ...CallOption) (*response.OnlineRefundResponse, error) {
 	if req == nil {
 		return nil, errors.NewBusinessError(
 			constant.ErrorCodeParameterError,
@@ -401,7 +499,7 @@ func (c *NexusClient) OnlineRefund(ctx context.Context, req *request.OnlineRefun
 	}
 
 	resp := &response.OnlineRefundResponse{}
-	err := c.httpClient.Post(constant.PathCheckoutRefund, req, resp)
+	err := c.postTransaction(ctx, constant.PathCheckoutRefund, req, resp, req.AppID, req.MerchantID, req.TransactionRequestID, requestOptions(constant.OperationOnlineRefund, opts))
 	if err != nil {
 		return nil, err
 	}
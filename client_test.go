@@ -0,0 +1,209 @@
+package nexus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	nexushttp "github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/ratelimit"
+)
+
+var fastRetryPolicy = &nexushttp.ExponentialBackoff{MaxRetries: 1, BaseDelay: time.Millisecond}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(args ...interface{})                 {}
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+func (nopLogger) Info(args ...interface{})                  {}
+func (nopLogger) Infof(format string, args ...interface{})  {}
+func (nopLogger) Warn(args ...interface{})                  {}
+func (nopLogger) Warnf(format string, args ...interface{})  {}
+func (nopLogger) Error(args ...interface{})                 {}
+func (nopLogger) Errorf(format string, args ...interface{}) {}
+
+func newTestNexusClient(t *testing.T, handler http.HandlerFunc, config Config) *NexusClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config.APIKey = "test-api-key"
+	config.BaseURL = server.URL
+	config.Logger = nopLogger{}
+	client, err := NewNexusClient(&config)
+	if err != nil {
+		t.Fatalf("NewNexusClient() returned error: %v", err)
+	}
+	return client
+}
+
+func TestSaleReconcilesAmbiguousFailureWithQuery(t *testing.T) {
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathSale:
+			w.WriteHeader(http.StatusBadGateway)
+		case constant.PathQuery:
+			if got := r.URL.Query().Get("transactionRequestId"); got != "REQ_1" {
+				t.Errorf("query transactionRequestId = %q, want %q", got, "REQ_1")
+			}
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionRequestId":"REQ_1","transactionStatus":"S"}}`))
+		}
+	}, Config{RetryPolicy: fastRetryPolicy, IdempotentRetry: true})
+
+	resp, err := client.Sale(context.Background(), &request.SaleRequest{
+		AppID:                "app",
+		MerchantID:           "mch",
+		TransactionRequestID: "REQ_1",
+	})
+	if err != nil {
+		t.Fatalf("Sale() returned error: %v", err)
+	}
+	if resp.TransactionID != "TX_1" || resp.TransactionStatus != "S" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.Meta == nil || resp.Meta.StatusCode != http.StatusBadGateway || resp.Meta.Attempts != 2 {
+		t.Fatalf("Meta = %+v, want the metadata of the failed Sale call", resp.Meta)
+	}
+}
+
+func TestSaleReconcileReportsMissingTransaction(t *testing.T) {
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathSale:
+			w.WriteHeader(http.StatusBadGateway)
+		case constant.PathQuery:
+			_, _ = w.Write([]byte(`{"code":"T404","msg":"transaction not found"}`))
+		}
+	}, Config{RetryPolicy: fastRetryPolicy, IdempotentRetry: true, TransactionNotFoundCodes: []string{"T404"}})
+
+	_, err := client.Sale(context.Background(), &request.SaleRequest{TransactionRequestID: "REQ_1"})
+	netErr, ok := err.(*errors.NetworkError)
+	if !ok {
+		t.Fatalf("Sale() error = %v, want NetworkError", err)
+	}
+	if !netErr.IsRetryable() || !strings.Contains(netErr.Error(), "no transaction found") {
+		t.Fatalf("Sale() error = %v, want a retryable NetworkError reporting no transaction found", netErr)
+	}
+}
+
+func TestSaleReconcileKeepsAmbiguousErrorWhenQueryRejected(t *testing.T) {
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathSale:
+			w.WriteHeader(http.StatusBadGateway)
+		case constant.PathQuery:
+			_, _ = w.Write([]byte(`{"code":"A401","msg":"invalid api key"}`))
+		}
+	}, Config{RetryPolicy: fastRetryPolicy, IdempotentRetry: true})
+
+	_, err := client.Sale(context.Background(), &request.SaleRequest{TransactionRequestID: "REQ_1"})
+	netErr, ok := err.(*errors.NetworkError)
+	if !ok {
+		t.Fatalf("Sale() error = %v, want NetworkError", err)
+	}
+	if netErr.Meta() == nil || netErr.Meta().StatusCode != http.StatusBadGateway {
+		t.Fatalf("Sale() error = %v, want the original ambiguous error of the Sale call", err)
+	}
+}
+
+func TestCustomHTTPClientIsUsed(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-api-key" {
+			t.Errorf("Authorization = %q, want SDK auth header", got)
+		}
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionStatus":"S"}}`))
+	}))
+	defer server.Close()
+
+	// The test server uses a self-signed certificate only trusted by its own client
+	client, err := NewNexusClient(&Config{
+		APIKey:     "test-api-key",
+		BaseURL:    server.URL,
+		Logger:     nopLogger{},
+		HTTPClient: server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("NewNexusClient() returned error: %v", err)
+	}
+
+	resp, err := client.Query(context.Background(), &request.QueryRequest{TransactionID: "TX_1"})
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	if resp.TransactionID != "TX_1" {
+		t.Fatalf("TransactionID = %q, want %q", resp.TransactionID, "TX_1")
+	}
+}
+
+func TestCallOptions(t *testing.T) {
+	attempts := 0
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if got := r.Header.Get("X-Client-Request-Id"); got != "my-request-id" {
+			t.Errorf("X-Client-Request-Id = %q, want %q", got, "my-request-id")
+		}
+		if got := r.Header.Get("X-Tenant-Id"); got != "tenant-1" {
+			t.Errorf("X-Tenant-Id = %q, want %q", got, "tenant-1")
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer other-key" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer other-key")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, Config{RetryPolicy: fastRetryPolicy})
+
+	_, err := client.Query(context.Background(), &request.QueryRequest{TransactionID: "TX_1"},
+		WithClientRequestID("my-request-id"),
+		WithHeader("X-Tenant-Id", "tenant-1"),
+		WithAPIKey("other-key"),
+		WithMaxRetries(0),
+	)
+	netErr, ok := err.(*errors.NetworkError)
+	if !ok {
+		t.Fatalf("Query() error = %v, want NetworkError", err)
+	}
+	if attempts != 1 || netErr.Meta().RequestID != "my-request-id" {
+		t.Fatalf("attempts = %d, meta = %+v; want a single attempt with the caller request ID", attempts, netErr.Meta())
+	}
+}
+
+func TestRateLimiterTakesTokenPerAttempt(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.Config{PerMerchant: &ratelimit.Limit{Rate: 1000, Burst: 10}})
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, Config{RetryPolicy: fastRetryPolicy, RateLimiter: limiter})
+
+	_, err := client.Query(context.Background(), &request.QueryRequest{MerchantID: "mch", TransactionID: "TX_1"})
+	if _, ok := err.(*errors.NetworkError); !ok {
+		t.Fatalf("Query() error = %v, want NetworkError", err)
+	}
+	if got := limiter.Metrics().Requests; got != 2 {
+		t.Fatalf("limiter saw %d requests, want one per attempt (2)", got)
+	}
+}
+
+func TestIdempotentRetryOnlyResendsTransactionsWithRequestID(t *testing.T) {
+	attempts := map[string]int{}
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts[r.URL.Path]++
+		w.WriteHeader(http.StatusBadGateway)
+	}, Config{RetryPolicy: fastRetryPolicy, IdempotentRetry: true})
+	ctx := context.Background()
+
+	_, _ = client.Abort(ctx, &request.AbortRequest{TerminalSN: "T1"}, WithIdempotentRetry(true))
+	_, _ = client.TipAdjust(ctx, &request.TipAdjustRequest{TerminalSN: "T1"})
+	_, _ = client.Sale(ctx, &request.SaleRequest{})
+	if attempts[constant.PathAbort] != 1 || attempts[constant.PathTipAdjust] != 1 || attempts[constant.PathSale] != 1 {
+		t.Fatalf("attempts = %v, want a single attempt for calls without an idempotency key", attempts)
+	}
+
+	_, _ = client.Sale(ctx, &request.SaleRequest{TransactionRequestID: "REQ_1"}, WithIdempotentRetry(true))
+	if attempts[constant.PathSale] != 3 {
+		t.Fatalf("Sale attempts = %d, want 1 + 2 for the retried call", attempts[constant.PathSale])
+	}
+}
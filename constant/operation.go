@@ -0,0 +1,20 @@
+package constant
+
+// Operation names identify SDK API calls, e.g. in per-operation timeout profiles
+const (
+	OperationSale                  = "Sale"
+	OperationAuth                  = "Auth"
+	OperationForcedAuth            = "ForcedAuth"
+	OperationIncrementalAuth       = "IncrementalAuth"
+	OperationPostAuth              = "PostAuth"
+	OperationRefund                = "Refund"
+	OperationVoid                  = "Void"
+	OperationAbort                 = "Abort"
+	OperationTipAdjust             = "TipAdjust"
+	OperationQuery                 = "Query"
+	OperationBatchClose            = "BatchClose"
+	OperationBatchQuery            = "BatchQuery"
+	OperationCreateCheckoutSession = "CreateCheckoutSession"
+	OperationDirectPayment         = "DirectPayment"
+	OperationOnlineRefund          = "OnlineRefund"
+)
@@ -1,6 +1,10 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+)
 
 // BusinessError represents a business error
 // Used for API business errors and parameter validation errors
@@ -8,6 +12,7 @@ type BusinessError struct {
 	code    string
 	message string
 	traceID string
+	meta    *common.ResponseMeta
 }
 
 // NewBusinessError creates a business error
@@ -42,3 +47,26 @@ func (e *BusinessError) TraceID() string {
 	return e.traceID
 }
 
+// Meta returns the HTTP metadata of the call (nil for errors raised before a request was sent)
+func (e *BusinessError) Meta() *common.ResponseMeta {
+	return e.meta
+}
+
+// SetMeta sets the HTTP metadata of the call
+func (e *BusinessError) SetMeta(meta *common.ResponseMeta) {
+	e.meta = meta
+}
+
+// HasCode reports whether err is a business error with one of the codes
+func HasCode(err error, codes ...string) bool {
+	bizErr, ok := err.(*BusinessError)
+	if !ok {
+		return false
+	}
+	for _, code := range codes {
+		if bizErr.code == code {
+			return true
+		}
+	}
+	return false
+}
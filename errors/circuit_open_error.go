@@ -0,0 +1,36 @@
+package errors
+
+import (
+	"fmt"
+	"time"
+)
+
+// CircuitOpenError is returned without sending the request when the circuit breaker
+// for the API path is open, so callers can immediately fall back, e.g. to an offline flow
+type CircuitOpenError struct {
+	path       string
+	retryAfter time.Duration
+}
+
+// NewCircuitOpenError creates a circuit open error
+func NewCircuitOpenError(path string, retryAfter time.Duration) *CircuitOpenError {
+	return &CircuitOpenError{
+		path:       path,
+		retryAfter: retryAfter,
+	}
+}
+
+// Error implements the error interface
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("CircuitOpenError{path='%s', retryAfter=%v}", e.path, e.retryAfter)
+}
+
+// Path returns the API path whose circuit is open
+func (e *CircuitOpenError) Path() string {
+	return e.path
+}
+
+// RetryAfter returns the time until the circuit lets a probe request through
+func (e *CircuitOpenError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
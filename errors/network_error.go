@@ -1,12 +1,18 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+)
 
 // NetworkError represents a network error
 type NetworkError struct {
 	message   string
 	retryable bool
 	cause     error
+	attempts  int
+	meta      *common.ResponseMeta
 }
 
 // NewNetworkError creates a network error
@@ -28,6 +34,26 @@ func (e *NetworkError) Unwrap() error {
 	return e.cause
 }
 
+// Attempts returns the number of attempts made before the error was returned (0 if unknown)
+func (e *NetworkError) Attempts() int {
+	return e.attempts
+}
+
+// SetAttempts sets the number of attempts made before the error was returned
+func (e *NetworkError) SetAttempts(attempts int) {
+	e.attempts = attempts
+}
+
+// Meta returns the HTTP metadata of the call (nil for errors raised before a request was sent)
+func (e *NetworkError) Meta() *common.ResponseMeta {
+	return e.meta
+}
+
+// SetMeta sets the HTTP metadata of the call
+func (e *NetworkError) SetMeta(meta *common.ResponseMeta) {
+	e.meta = meta
+}
+
 // IsRetryable returns whether the error is retryable
 func (e *NetworkError) IsRetryable() bool {
 	return e.retryable
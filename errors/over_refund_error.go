@@ -0,0 +1,59 @@
+package errors
+
+import "fmt"
+
+// OverRefundError represents a refund rejected locally because it exceeds what is still refundable
+type OverRefundError struct {
+	component  string
+	currency   string
+	requested  int64
+	refundable int64
+	reason     string
+}
+
+// NewOverRefundError creates an over-refund error
+// component names the amount part exceeded (order, tip, tax, surcharge or total)
+func NewOverRefundError(component, currency string, requested, refundable int64, reason string) *OverRefundError {
+	return &OverRefundError{
+		component:  component,
+		currency:   currency,
+		requested:  requested,
+		refundable: refundable,
+		reason:     reason,
+	}
+}
+
+// Error implements the error interface
+func (e *OverRefundError) Error() string {
+	if e.reason != "" {
+		return fmt.Sprintf("OverRefundError{component='%s', requested=%d, refundable=%d, currency='%s', reason='%s'}",
+			e.component, e.requested, e.refundable, e.currency, e.reason)
+	}
+	return fmt.Sprintf("OverRefundError{component='%s', requested=%d, refundable=%d, currency='%s'}",
+		e.component, e.requested, e.refundable, e.currency)
+}
+
+// Component returns the amount part that was exceeded
+func (e *OverRefundError) Component() string {
+	return e.component
+}
+
+// Currency returns the currency of the amounts
+func (e *OverRefundError) Currency() string {
+	return e.currency
+}
+
+// Requested returns the requested amount in cents
+func (e *OverRefundError) Requested() int64 {
+	return e.requested
+}
+
+// Refundable returns the amount still refundable in cents
+func (e *OverRefundError) Refundable() int64 {
+	return e.refundable
+}
+
+// Reason returns why the transaction cannot be refunded at all, empty when only the amount is too high
+func (e *OverRefundError) Reason() string {
+	return e.reason
+}
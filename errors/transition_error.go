@@ -0,0 +1,49 @@
+package errors
+
+import "fmt"
+
+// TransitionError represents a transaction state change that the transaction lifecycle does not allow,
+// such as a settled payment reported as processing again
+type TransitionError struct {
+	transactionID string
+	source        string
+	from          string
+	to            string
+}
+
+// NewTransitionError creates a transition error
+// source names where the new state was observed (QUERY or WEBHOOK)
+func NewTransitionError(transactionID, source, from, to string) *TransitionError {
+	return &TransitionError{
+		transactionID: transactionID,
+		source:        source,
+		from:          from,
+		to:            to,
+	}
+}
+
+// Error implements the error interface
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("TransitionError{transactionId='%s', from='%s', to='%s', source='%s'}",
+		e.transactionID, e.from, e.to, e.source)
+}
+
+// TransactionID returns the ID of the transaction
+func (e *TransitionError) TransactionID() string {
+	return e.transactionID
+}
+
+// Source returns where the rejected state was observed
+func (e *TransitionError) Source() string {
+	return e.source
+}
+
+// From returns the last accepted state
+func (e *TransitionError) From() string {
+	return e.from
+}
+
+// To returns the rejected state
+func (e *TransitionError) To() string {
+	return e.to
+}
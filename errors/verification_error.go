@@ -0,0 +1,41 @@
+package errors
+
+import "fmt"
+
+// Verification failure reasons
+const (
+	VerificationReasonMissingSignature = "MISSING_SIGNATURE"
+	VerificationReasonInvalidSignature = "INVALID_SIGNATURE"
+	VerificationReasonMissingTimestamp = "MISSING_TIMESTAMP"
+	VerificationReasonInvalidTimestamp = "INVALID_TIMESTAMP"
+	VerificationReasonExpired          = "TIMESTAMP_OUT_OF_WINDOW"
+)
+
+// VerificationError represents a notification rejected because its authenticity could not be verified
+type VerificationError struct {
+	reason  string
+	message string
+}
+
+// NewVerificationError creates a verification error
+func NewVerificationError(reason, message string) *VerificationError {
+	return &VerificationError{
+		reason:  reason,
+		message: message,
+	}
+}
+
+// Error implements the error interface
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("VerificationError{reason='%s', message='%s'}", e.reason, e.message)
+}
+
+// Reason returns the failure reason, see VerificationReason*
+func (e *VerificationError) Reason() string {
+	return e.reason
+}
+
+// Message returns the error message
+func (e *VerificationError) Message() string {
+	return e.message
+}
@@ -1,4 +1,3 @@
 module github.com/sunbay-developer/sunbay-nexus-sdk-go
 
 go 1.18
-
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenTimeout      = 30 * time.Second
+	defaultBreakerHalfOpenRequests = 1
+)
+
+// CircuitState is the state of a circuit
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen rejects all requests until the open timeout elapses
+	CircuitOpen
+
+	// CircuitHalfOpen lets a limited number of probe requests through
+	CircuitHalfOpen
+)
+
+// String returns the circuit state name
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "CLOSED"
+	case CircuitOpen:
+		return "OPEN"
+	case CircuitHalfOpen:
+		return "HALF_OPEN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// CircuitBreakerConfig holds the circuit breaker configuration
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit (optional, defaults to 5)
+	FailureThreshold int
+
+	// OpenTimeout is how long the circuit stays open before probe requests are let through (optional, defaults to 30s)
+	OpenTimeout time.Duration
+
+	// HalfOpenMaxRequests is the maximum number of concurrent probe requests while half-open (optional, defaults to 1)
+	HalfOpenMaxRequests int
+}
+
+// CircuitBreaker tracks one circuit per API path
+// Network errors, timeouts and HTTP 5xx/429 responses count as failures; business errors count as successes
+// because the gateway responded. The breaker is safe for concurrent use
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	config   CircuitBreakerConfig
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+// circuit is the state of a single API path
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// NewCircuitBreaker creates a circuit breaker, applying defaults to zero config values
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaultBreakerFailureThreshold
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if config.HalfOpenMaxRequests <= 0 {
+		config.HalfOpenMaxRequests = defaultBreakerHalfOpenRequests
+	}
+	return &CircuitBreaker{
+		config:   config,
+		circuits: make(map[string]*circuit),
+		now:      time.Now,
+	}
+}
+
+// State returns the current state of the circuit for path
+func (b *CircuitBreaker) State(path string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[path]
+	if !ok {
+		return CircuitClosed
+	}
+	if c.state == CircuitOpen && b.now().Sub(c.openedAt) >= b.config.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// Allow reports whether a request to path may be sent
+// On success the returned function must be called exactly once with the outcome of the request
+func (b *CircuitBreaker) Allow(path string) (func(err error), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[path]
+	if !ok {
+		c = &circuit{}
+		b.circuits[path] = c
+	}
+
+	probe := false
+	switch c.state {
+	case CircuitOpen:
+		elapsed := b.now().Sub(c.openedAt)
+		if elapsed < b.config.OpenTimeout {
+			return nil, errors.NewCircuitOpenError(path, b.config.OpenTimeout-elapsed)
+		}
+		c.state = CircuitHalfOpen
+		c.probes = 0
+		fallthrough
+	case CircuitHalfOpen:
+		if c.probes >= b.config.HalfOpenMaxRequests {
+			return nil, errors.NewCircuitOpenError(path, 0)
+		}
+		c.probes++
+		probe = true
+	}
+
+	return func(err error) {
+		b.record(c, probe, err)
+	}, nil
+}
+
+// record updates the circuit with the outcome of a request
+func (b *CircuitBreaker) record(c *circuit, probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		c.probes--
+	}
+
+	switch err.(type) {
+	case nil, *errors.BusinessError:
+		if probe || c.state == CircuitClosed {
+			c.state = CircuitClosed
+			c.failures = 0
+		}
+	case *errors.NetworkError:
+		c.failures++
+		if probe || (c.state == CircuitClosed && c.failures >= b.config.FailureThreshold) {
+			c.state = CircuitOpen
+			c.openedAt = b.now()
+		}
+	default:
+		// Canceled calls and client-side errors say nothing about the health of the gateway
+	}
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	breaker.now = func() time.Time { return now }
+
+	netErr := errors.NewNetworkError("boom", true, nil)
+	for i := 0; i < 2; i++ {
+		done, err := breaker.Allow("/sale")
+		if err != nil {
+			t.Fatalf("Allow() #%d returned error: %v", i, err)
+		}
+		done(netErr)
+	}
+	if state := breaker.State("/sale"); state != CircuitOpen {
+		t.Fatalf("State() = %v, want OPEN", state)
+	}
+	if _, err := breaker.Allow("/sale"); err == nil {
+		t.Fatal("Allow() expected CircuitOpenError while open")
+	} else if _, ok := err.(*errors.CircuitOpenError); !ok {
+		t.Fatalf("Allow() error = %T, want *errors.CircuitOpenError", err)
+	}
+
+	// Other paths are unaffected
+	if _, err := breaker.Allow("/query"); err != nil {
+		t.Fatalf("Allow() for another path returned error: %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	probeDone, err := breaker.Allow("/sale")
+	if err != nil {
+		t.Fatalf("Allow() probe returned error: %v", err)
+	}
+	if _, err := breaker.Allow("/sale"); err == nil {
+		t.Fatal("Allow() expected error for a second concurrent probe")
+	}
+	probeDone(errors.NewBusinessError("E01", "declined", ""))
+	if state := breaker.State("/sale"); state != CircuitClosed {
+		t.Fatalf("State() after successful probe = %v, want CLOSED", state)
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceledCalls(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1})
+	done, err := breaker.Allow("/sale")
+	if err != nil {
+		t.Fatalf("Allow() returned error: %v", err)
+	}
+	done(context.Canceled)
+	if state := breaker.State("/sale"); state != CircuitClosed {
+		t.Fatalf("State() = %v, want CLOSED", state)
+	}
+}
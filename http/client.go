@@ -2,9 +2,11 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
 	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
 	"github.com/sunbay-developer/sunbay-nexus-sdk-go/util"
 )
 
@@ -24,7 +27,8 @@ const (
 	headerTimestamp     = "X-Timestamp"
 	headerUserAgent     = "User-Agent"
 	contentTypeJSON     = "application/json"
-	retryDelayBase      = 1 * time.Second
+
+	defaultTLSHandshakeTimeout = 10 * time.Second
 )
 
 // Logger is the logging interface that allows integration with any logging library
@@ -80,6 +84,11 @@ func (l *defaultLogger) Errorf(format string, args ...interface{}) {
 	fmt.Printf("[ERROR] %s\n", fmt.Sprintf(format, args...))
 }
 
+// DefaultLogger returns the console logger used when no logger is configured
+func DefaultLogger() Logger {
+	return &defaultLogger{}
+}
+
 // getLogger gets the logger (returns default logger if nil)
 func getLogger(logger Logger) Logger {
 	if logger == nil {
@@ -90,39 +99,136 @@ func getLogger(logger Logger) Logger {
 
 // Client is the HTTP client
 type Client struct {
-	apiKey     string
-	baseURL    string
-	httpClient *http.Client
-	maxRetries int
-	retryDelay time.Duration
-	logger     Logger
+	apiKey      string
+	baseURL     string
+	httpClient  *http.Client
+	transport   *http.Transport
+	readTimeout time.Duration
+	retryPolicy RetryPolicy
+	retryPost   bool
+	middlewares []Middleware
+	breaker     *CircuitBreaker
+	attemptGate AttemptGate
+	logger      Logger
+
+	operationTimeouts map[string]time.Duration
 }
 
 // NewClient creates a new HTTP client
+// connectTimeout bounds TCP connection establishment and readTimeout bounds each attempt, both in milliseconds.
+// maxRetries is the number of retries after the initial attempt used by the default ExponentialBackoff policy
 func NewClient(apiKey, baseURL string, connectTimeout, readTimeout, maxRetries, maxTotal, maxPerRoute int, logger Logger) *Client {
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(connectTimeout) * time.Millisecond,
+		KeepAlive: 30 * time.Second,
+	}
+
 	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: defaultTLSHandshakeTimeout,
 		MaxIdleConns:        maxTotal,
 		MaxIdleConnsPerHost: maxPerRoute,
 		IdleConnTimeout:     90 * time.Second,
 	}
 
+	// The attempt timeout is applied per request through its context so it can vary by operation
 	httpClient := &http.Client{
 		Transport: transport,
-		Timeout:   time.Duration(readTimeout) * time.Millisecond,
 	}
 
 	return &Client{
-		apiKey:     apiKey,
-		baseURL:    baseURL,
-		httpClient: httpClient,
-		maxRetries: maxRetries,
-		retryDelay: retryDelayBase,
-		logger:     logger,
+		apiKey:      apiKey,
+		baseURL:     baseURL,
+		httpClient:  httpClient,
+		transport:   transport,
+		readTimeout: time.Duration(readTimeout) * time.Millisecond,
+		retryPolicy: NewExponentialBackoff(maxRetries),
+		logger:      logger,
+	}
+}
+
+// SetHTTPClient replaces the underlying *http.Client (nil keeps the current client)
+// The SDK still applies authentication headers, attempt timeouts and retries on top of it,
+// but never modifies the supplied client or its transport
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	if httpClient != nil {
+		c.httpClient = httpClient
+		c.transport = nil
+	}
+}
+
+// SetTransport replaces the http.RoundTripper used to send requests (nil keeps the current transport)
+// Connection pool, connect and TLS settings of the SDK are not applied to a supplied transport
+func (c *Client) SetTransport(transport http.RoundTripper) {
+	if transport != nil {
+		c.httpClient = &http.Client{Transport: transport}
+		c.transport = nil
+	}
+}
+
+// SetTransportTimeouts sets the TLS handshake timeout and the timeout for receiving response headers
+// after the request is written. Zero keeps the current TLS handshake timeout and disables the response header timeout.
+// It has no effect when a custom HTTP client or transport is used
+func (c *Client) SetTransportTimeouts(tlsHandshakeTimeout, responseHeaderTimeout time.Duration) {
+	if c.transport == nil {
+		return
 	}
+	if tlsHandshakeTimeout > 0 {
+		c.transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	}
+	c.transport.ResponseHeaderTimeout = responseHeaderTimeout
+}
+
+// SetOperationTimeouts sets per-operation attempt timeouts keyed by operation name (see constant.Operation*)
+// Operations not in the map use the read timeout
+func (c *Client) SetOperationTimeouts(timeouts map[string]time.Duration) {
+	c.operationTimeouts = make(map[string]time.Duration, len(timeouts))
+	for operation, timeout := range timeouts {
+		c.operationTimeouts[operation] = timeout
+	}
+}
+
+// SetRetryPolicy replaces the retry policy (nil keeps the current policy)
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	if policy != nil {
+		c.retryPolicy = policy
+	}
+}
+
+// Use appends middleware to the chain applied to every request
+// Middleware added first is outermost. Use must not be called concurrently with requests
+func (c *Client) Use(middleware ...Middleware) {
+	for _, m := range middleware {
+		if m != nil {
+			c.middlewares = append(c.middlewares, m)
+		}
+	}
+}
+
+// SetCircuitBreaker sets the per-path circuit breaker (nil disables it)
+func (c *Client) SetCircuitBreaker(breaker *CircuitBreaker) {
+	c.breaker = breaker
+}
+
+// SetAttemptGate sets the gate called before every attempt (nil disables it)
+func (c *Client) SetAttemptGate(gate AttemptGate) {
+	c.attemptGate = gate
+}
+
+// SetRetryPost enables or disables automatic retry of POST requests
+// Only enable this when every POST body carries an idempotency key (transactionRequestId),
+// because a retried POST is sent with the identical body and may reach the server more than once
+func (c *Client) SetRetryPost(enabled bool) {
+	c.retryPost = enabled
 }
 
 // Post executes a POST request
-func (c *Client) Post(path string, requestBody interface{}, responseType interface{}) error {
+// The context controls the whole call, including any retry delays; opts may be nil
+func (c *Client) Post(ctx context.Context, path string, requestBody interface{}, responseType interface{}, opts *RequestOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	url := c.baseURL + path
 	if v, ok := requestBody.(validator); ok {
 		if err := v.Validate(); err != nil {
@@ -131,47 +237,91 @@ func (c *Client) Post(path string, requestBody interface{}, responseType interfa
 	}
 	requestJSON := util.ToJSON(requestBody)
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(requestJSON))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(requestJSON))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
 
 	c.addCommonHeaders(req, "POST")
+	applyRequestOptions(req, opts)
 
-	// Log request
-	headers := make(map[string]string)
-	for k, v := range req.Header {
-		if len(v) > 0 {
-			headers[k] = v[0]
-		}
+	retryable := c.retryPost
+	if opts != nil && opts.RetryPost != nil {
+		retryable = *opts.RetryPost
 	}
-	c.logRequest("POST", url, headers, requestJSON)
 
-	return c.executeRequest(req, responseType, false)
+	call := newCall(path, requestBody, responseType, req, opts)
+	return c.dispatch(ctx, call, requestJSON, retryable, opts)
 }
 
 // Get executes a GET request
-func (c *Client) Get(path string, request interface{}, responseType interface{}) error {
+// The context controls the whole call, including any retry delays; opts may be nil
+func (c *Client) Get(ctx context.Context, path string, request interface{}, responseType interface{}, opts *RequestOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	baseURL := c.baseURL + path
 	urlStr := c.buildQueryURL(baseURL, request)
 
-	req, err := http.NewRequest("GET", urlStr, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
 
 	c.addCommonHeaders(req, "GET")
+	applyRequestOptions(req, opts)
 
-	// Log request
-	headers := make(map[string]string)
-	for k, v := range req.Header {
-		if len(v) > 0 {
-			headers[k] = v[0]
-		}
+	call := newCall(path, request, responseType, req, opts)
+	return c.dispatch(ctx, call, "", true, opts)
+}
+
+// dispatch runs the call through the middleware chain
+// The innermost handler checks the circuit breaker, logs the (possibly modified) request and executes it with retry
+func (c *Client) dispatch(ctx context.Context, call *Call, body string, retryable bool, opts *RequestOptions) error {
+	policy := c.retryPolicy
+	if opts != nil && opts.MaxRetries != nil {
+		policy = maxRetriesPolicy{RetryPolicy: policy, maxRetries: *opts.MaxRetries}
 	}
-	c.logRequest("GET", urlStr, headers, "")
+	timeout := c.attemptTimeout(opts)
+
+	handler := Handler(func(ctx context.Context, call *Call) (err error) {
+		req := call.HTTPRequest
+
+		if c.breaker != nil {
+			done, openErr := c.breaker.Allow(call.Path)
+			if openErr != nil {
+				c.logError(req.Method, req.URL.String(), openErr)
+				return openErr
+			}
+			defer func() { done(err) }()
+		}
+
+		// Log request
+		headers := make(map[string]string)
+		for k, v := range req.Header {
+			if len(v) > 0 {
+				headers[k] = v[0]
+			}
+		}
+		c.logRequest(req.Method, req.URL.String(), headers, body)
 
-	return c.executeRequest(req, responseType, true)
+		return c.executeRequest(ctx, call, retryable, policy, timeout)
+	})
+	return chain(c.middlewares, handler)(ctx, call)
+}
+
+// attemptTimeout resolves the timeout of each attempt
+// A per-request timeout takes precedence over the operation profile, which takes precedence over the read timeout
+func (c *Client) attemptTimeout(opts *RequestOptions) time.Duration {
+	if opts != nil {
+		if opts.Timeout > 0 {
+			return opts.Timeout
+		}
+		if timeout, ok := c.operationTimeouts[opts.Operation]; ok && timeout > 0 {
+			return timeout
+		}
+	}
+	return c.readTimeout
 }
 
 // addCommonHeaders adds common request headers
@@ -255,76 +405,170 @@ func (c *Client) buildQueryURL(baseURL string, request interface{}) string {
 }
 
 // executeRequest executes HTTP request with retry
-func (c *Client) executeRequest(req *http.Request, responseType interface{}, retryable bool) error {
-	maxAttempts := 1
-	if retryable {
-		maxAttempts = c.maxRetries
-	}
-
-	var lastErr error
+// Whether a failed attempt is retried, and after which delay, is decided by the retry policy.
+// Each attempt is bounded by timeout and a timed-out attempt is reported as a retryable NetworkError.
+// Cancellation or expiry of ctx aborts the in-flight request and any pending retry delay,
+// and is returned as ctx.Err() rather than as a NetworkError
+func (c *Client) executeRequest(ctx context.Context, call *Call, retryable bool, policy RetryPolicy, timeout time.Duration) error {
+	req := call.HTTPRequest.WithContext(ctx)
 	method := req.Method
 	urlStr := req.URL.String()
+	start := time.Now()
 
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			lastErr = err
-			if !retryable || attempt >= maxAttempts {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			if err := rewindBody(req); err != nil {
 				c.logError(method, urlStr, err)
-				return errors.NewNetworkError("Network error: "+err.Error(), true, err)
+				return errors.NewNetworkError("Failed to rewind request body: "+err.Error(), false, err)
 			}
-			c.logRetry(attempt, maxAttempts, err.Error())
-			time.Sleep(c.retryDelay * time.Duration(attempt))
-			continue
 		}
 
-		// Read response body for logging
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			lastErr = err
-			if !retryable || attempt >= maxAttempts {
+		if c.attemptGate != nil {
+			if err := c.attemptGate(ctx, call); err != nil {
 				c.logError(method, urlStr, err)
-				return errors.NewNetworkError("Failed to read response body: "+err.Error(), false, err)
+				if lastErr != nil {
+					// An earlier attempt was sent; its failure still describes the call
+					return lastErr
+				}
+				return err
 			}
-			c.logRetry(attempt, maxAttempts, err.Error())
-			time.Sleep(c.retryDelay * time.Duration(attempt))
-			continue
 		}
-		resp.Body.Close()
-		bodyStr := string(bodyBytes)
-
-		// Log response
-		c.logResponse(method, urlStr, resp.StatusCode, bodyStr)
-
-		// Recreate response body for parsing
-		resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-
-		// Parse response
-		err = c.parseResponse(resp, responseType)
-		resp.Body.Close()
-
-		if err != nil {
-			// If network error and retryable, continue retrying
-			if netErr, ok := err.(*errors.NetworkError); ok && netErr.IsRetryable() && retryable && attempt < maxAttempts {
-				lastErr = err
-				c.logRetry(attempt, maxAttempts, err.Error())
-				time.Sleep(c.retryDelay * time.Duration(attempt))
-				continue
-			}
+
+		resp, err := c.doAttempt(ctx, req, call.Response, timeout)
+		call.HTTPResponse = resp
+		setMeta(call.Response, err, newResponseMeta(req, resp, attempt, start))
+		if err == nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			c.logCanceled(method, urlStr, ctxErr)
+			return ctxErr
+		}
+
+		state := &RetryState{
+			Attempt: attempt,
+			Elapsed: time.Since(start),
+			Method:  method,
+			Err:     err,
+		}
+		if resp != nil {
+			state.StatusCode = resp.StatusCode
+			state.Header = resp.Header
+		}
+		if netErr, ok := err.(*errors.NetworkError); ok {
+			netErr.SetAttempts(attempt)
+		}
+		if !retryable || !policy.ShouldRetry(state) {
 			c.logError(method, urlStr, err)
 			return err
 		}
+		lastErr = err
+
+		delay := policy.Delay(state)
+		c.logRetry(attempt, delay, err.Error())
+		if err := sleep(ctx, delay); err != nil {
+			c.logCanceled(method, urlStr, err)
+			return err
+		}
+	}
+}
+
+// newResponseMeta builds the HTTP metadata of a call after its last attempt
+func newResponseMeta(req *http.Request, resp *http.Response, attempts int, start time.Time) *common.ResponseMeta {
+	meta := &common.ResponseMeta{
+		RequestID: req.Header.Get(headerRequestID),
+		Attempts:  attempts,
+		Latency:   time.Since(start),
+	}
+	if resp != nil {
+		meta.StatusCode = resp.StatusCode
+		meta.Header = resp.Header
+	}
+	return meta
+}
+
+// setMeta attaches the HTTP metadata to the error if any, otherwise to the response
+func setMeta(response interface{}, err error, meta *common.ResponseMeta) {
+	target := response
+	if err != nil {
+		target = err
+	}
+	if m, ok := target.(interface {
+		SetMeta(meta *common.ResponseMeta)
+	}); ok {
+		m.SetMeta(meta)
+	}
+}
+
+// doAttempt sends the request once and parses the response
+// The response is returned whenever one was received, with its body replaced by an unread in-memory copy
+func (c *Client) doAttempt(ctx context.Context, req *http.Request, responseType interface{}, timeout time.Duration) (*http.Response, error) {
+	method := req.Method
+	urlStr := req.URL.String()
+
+	if timeout > 0 {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		req = req.WithContext(attemptCtx)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() == nil && req.Context().Err() != nil {
+			return nil, errors.NewNetworkError(fmt.Sprintf("Request timed out after %v", timeout), true, err)
+		}
+		return nil, errors.NewNetworkError("Network error: "+err.Error(), true, err)
+	}
+
+	// Read response body for logging
+	bodyBytes, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	if err != nil {
+		if ctx.Err() == nil && req.Context().Err() != nil {
+			return resp, errors.NewNetworkError(fmt.Sprintf("Request timed out after %v", timeout), true, err)
+		}
+		return resp, errors.NewNetworkError("Failed to read response body: "+err.Error(), true, err)
+	}
+
+	// Log response
+	c.logResponse(method, urlStr, resp.StatusCode, string(bodyBytes))
 
+	// Parse response
+	err = c.parseResponse(resp, responseType)
+
+	// Recreate response body for middleware
+	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	return resp, err
+}
+
+// rewindBody resets the request body so the identical payload is sent again on retry
+func rewindBody(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
 		return nil
 	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
 
-	finalErr := errors.NewNetworkError(
-		fmt.Sprintf("Request failed after %d attempts", maxAttempts),
-		retryable,
-		lastErr,
-	)
-	c.logError(method, urlStr, finalErr)
-	return finalErr
+// sleep waits for the given duration or until ctx is done, whichever comes first
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 // parseResponse parses HTTP response
@@ -338,7 +582,7 @@ func (c *Client) parseResponse(resp *http.Response, result interface{}) error {
 	if resp.StatusCode < constant.HTTPStatusOKStart || resp.StatusCode >= constant.HTTPStatusOKEnd {
 		return errors.NewNetworkError(
 			fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)),
-			resp.StatusCode >= constant.HTTPStatusServerErrorStart,
+			resp.StatusCode >= constant.HTTPStatusServerErrorStart || resp.StatusCode == http.StatusTooManyRequests,
 			nil,
 		)
 	}
@@ -421,9 +665,15 @@ func (c *Client) logResponse(method, url string, statusCode int, body string) {
 }
 
 // logRetry logs retry
-func (c *Client) logRetry(attempt, maxAttempts int, reason string) {
+func (c *Client) logRetry(attempt int, delay time.Duration, reason string) {
+	logger := getLogger(c.logger)
+	logger.Debugf("Request failed (attempt %d), retrying after %v: %s", attempt, delay, reason)
+}
+
+// logCanceled logs a request aborted by its context
+func (c *Client) logCanceled(method, url string, err error) {
 	logger := getLogger(c.logger)
-	logger.Debugf("Request failed, retrying (%d/%d) after delay: %s", attempt, maxAttempts, reason)
+	logger.Warnf("Request aborted %s %s: %v", method, url, err)
 }
 
 // logError logs error
@@ -431,6 +681,8 @@ func (c *Client) logError(method, url string, err error) {
 	logger := getLogger(c.logger)
 	if netErr, ok := err.(*errors.NetworkError); ok {
 		logger.Warnf("Network error %s %s: %v (retryable: %v)", method, url, netErr, netErr.IsRetryable())
+	} else if openErr, ok := err.(*errors.CircuitOpenError); ok {
+		logger.Warnf("Circuit open %s %s, request rejected (retry after %v)", method, url, openErr.RetryAfter())
 	} else if bizErr, ok := err.(*errors.BusinessError); ok {
 		logger.Errorf("API error %s %s - code: %s, msg: %s, traceID: %s",
 			method, url, bizErr.Code(), bizErr.Message(), bizErr.TraceID())
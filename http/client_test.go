@@ -0,0 +1,342 @@
+package http
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(args ...interface{})                 {}
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+func (nopLogger) Info(args ...interface{})                  {}
+func (nopLogger) Infof(format string, args ...interface{})  {}
+func (nopLogger) Warn(args ...interface{})                  {}
+func (nopLogger) Warnf(format string, args ...interface{})  {}
+func (nopLogger) Error(args ...interface{})                 {}
+func (nopLogger) Errorf(format string, args ...interface{}) {}
+
+type testResponse struct {
+	common.BaseResponse
+
+	Value string `json:"value"`
+}
+
+// fixedRetryPolicy retries retryable failures up to maxRetries times with a constant delay
+type fixedRetryPolicy struct {
+	maxRetries int
+	delay      time.Duration
+}
+
+func (p fixedRetryPolicy) ShouldRetry(state *RetryState) bool {
+	return state.Attempt <= p.maxRetries && NewExponentialBackoff(p.maxRetries).ShouldRetry(state)
+}
+
+func (p fixedRetryPolicy) Delay(state *RetryState) time.Duration {
+	return p.delay
+}
+
+func newTestClient(baseURL string) *Client {
+	return NewClient("test-api-key", baseURL, 1000, 5000, 3, 10, 10, nopLogger{})
+}
+
+func TestClientContextCancelAbortsInFlightRequest(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := newTestClient(server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := client.Post(ctx, "/sale", map[string]string{"a": "b"}, &testResponse{}, nil)
+	if !stderrors.Is(err, context.Canceled) {
+		t.Fatalf("Post() error = %v, want context.Canceled", err)
+	}
+	if _, ok := err.(*errors.NetworkError); ok {
+		t.Fatal("Post() returned NetworkError for a canceled context")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Post() took %v after cancel", elapsed)
+	}
+}
+
+func TestClientContextDeadlineAbortsRetryDelay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.SetRetryPolicy(fixedRetryPolicy{maxRetries: 3, delay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := client.Get(ctx, "/query", nil, &testResponse{}, nil)
+	if !stderrors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestClientGetParsesWrappedData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"value":"ok"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	resp := &testResponse{}
+	if err := client.Get(context.Background(), "/query", nil, resp, nil); err != nil {
+		t.Fatalf("Get() returned error: %v", err)
+	}
+	if resp.Value != "ok" {
+		t.Fatalf("Value = %q, want %q", resp.Value, "ok")
+	}
+}
+
+func TestClientRetryPostResendsIdenticalBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"value":"ok"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.SetRetryPolicy(fixedRetryPolicy{maxRetries: 3, delay: time.Millisecond})
+	client.SetRetryPost(true)
+
+	resp := &testResponse{}
+	if err := client.Post(context.Background(), "/sale", map[string]string{"transactionRequestId": "REQ_1"}, resp, nil); err != nil {
+		t.Fatalf("Post() returned error: %v", err)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("server saw %d attempts, want 2", len(bodies))
+	}
+	if bodies[0] != bodies[1] {
+		t.Fatalf("retry body differs:\nfirst  %s\nsecond %s", bodies[0], bodies[1])
+	}
+}
+
+func TestClientPostNotRetriedByDefault(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.SetRetryPolicy(fixedRetryPolicy{maxRetries: 3, delay: time.Millisecond})
+
+	if err := client.Post(context.Background(), "/sale", map[string]string{}, &testResponse{}, nil); err == nil {
+		t.Fatal("Post() expected error, got nil")
+	}
+	if attempts != 1 {
+		t.Fatalf("server saw %d attempts, want 1", attempts)
+	}
+}
+
+func TestClientFinalErrorReportsAttempts(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.SetRetryPolicy(fixedRetryPolicy{maxRetries: 2, delay: time.Millisecond})
+
+	err := client.Get(context.Background(), "/query", nil, &testResponse{}, nil)
+	netErr, ok := err.(*errors.NetworkError)
+	if !ok {
+		t.Fatalf("Get() error = %v, want NetworkError", err)
+	}
+	if attempts != 3 || netErr.Attempts() != 3 {
+		t.Fatalf("server saw %d attempts, error reports %d, want 3", attempts, netErr.Attempts())
+	}
+	if meta := netErr.Meta(); meta == nil || meta.Attempts != 3 || meta.StatusCode != http.StatusInternalServerError || meta.RequestID == "" {
+		t.Fatalf("unexpected error meta: %+v", meta)
+	}
+}
+
+func TestClientAttemptGateRunsBeforeEveryAttempt(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.SetRetryPolicy(fixedRetryPolicy{maxRetries: 3, delay: time.Millisecond})
+	gated := 0
+	stop := stderrors.New("no token")
+	client.SetAttemptGate(func(ctx context.Context, call *Call) error {
+		gated++
+		if gated == 3 {
+			return stop
+		}
+		return nil
+	})
+
+	// The gate stopping a retry returns the failure of the attempt already sent
+	err := client.Get(context.Background(), "/query", nil, &testResponse{}, nil)
+	if netErr, ok := err.(*errors.NetworkError); !ok || netErr.Attempts() != 2 {
+		t.Fatalf("Get() error = %v, want the NetworkError of attempt 2", err)
+	}
+	if gated != 3 || attempts != 2 {
+		t.Fatalf("gate called %d times for %d attempts, want 3 and 2", gated, attempts)
+	}
+
+	gated = 2
+	if err := client.Get(context.Background(), "/query", nil, &testResponse{}, nil); err != stop {
+		t.Fatalf("Get() error = %v, want the gate error before any attempt", err)
+	}
+}
+
+func TestClientResponseMeta(t *testing.T) {
+	var requestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = r.Header.Get("X-Client-Request-Id")
+		w.Header().Set("X-Server", "nexus")
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","traceId":"trace-1","data":{"value":"ok"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	resp := &testResponse{}
+	if err := client.Get(context.Background(), "/query", nil, resp, nil); err != nil {
+		t.Fatalf("Get() returned error: %v", err)
+	}
+
+	meta := resp.Meta
+	if meta == nil {
+		t.Fatal("Meta is nil")
+	}
+	if meta.RequestID == "" || meta.RequestID != requestID {
+		t.Fatalf("RequestID = %q, want %q", meta.RequestID, requestID)
+	}
+	if meta.StatusCode != http.StatusOK || meta.Attempts != 1 || meta.Header.Get("X-Server") != "nexus" || meta.Latency <= 0 {
+		t.Fatalf("unexpected meta: %+v", meta)
+	}
+	if resp.TraceID != "trace-1" {
+		t.Fatalf("TraceID = %q, want %q", resp.TraceID, "trace-1")
+	}
+}
+
+func TestClientOperationAndRequestTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"value":"ok"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	client.SetRetryPolicy(fixedRetryPolicy{maxRetries: 0})
+	client.SetOperationTimeouts(map[string]time.Duration{"Query": 20 * time.Millisecond})
+
+	err := client.Get(context.Background(), "/query", nil, &testResponse{}, &RequestOptions{Operation: "Query"})
+	netErr, ok := err.(*errors.NetworkError)
+	if !ok || !netErr.IsRetryable() {
+		t.Fatalf("Get() error = %v, want retryable NetworkError", err)
+	}
+
+	opts := &RequestOptions{Operation: "Query", Timeout: 2 * time.Second}
+	if err := client.Get(context.Background(), "/query", nil, &testResponse{}, opts); err != nil {
+		t.Fatalf("Get() with per-request timeout returned error: %v", err)
+	}
+
+	if err := client.Get(context.Background(), "/query", nil, &testResponse{}, &RequestOptions{Operation: "Sale"}); err != nil {
+		t.Fatalf("Get() with default timeout returned error: %v", err)
+	}
+}
+
+func TestClientMiddlewareChain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Tenant-Id"); got != "tenant-1" {
+			t.Errorf("X-Tenant-Id = %q, want %q", got, "tenant-1")
+		}
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"value":"ok"}}`))
+	}))
+	defer server.Close()
+
+	var order []string
+	var seen *Call
+	client := newTestClient(server.URL)
+	client.Use(
+		func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) error {
+				order = append(order, "outer")
+				err := next(ctx, call)
+				seen = call
+				return err
+			}
+		},
+		func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) error {
+				order = append(order, "inner")
+				call.HTTPRequest.Header.Set("X-Tenant-Id", "tenant-1")
+				return next(ctx, call)
+			}
+		},
+	)
+
+	req := map[string]string{"a": "b"}
+	if err := client.Post(context.Background(), "/sale", req, &testResponse{}, &RequestOptions{Operation: "Sale"}); err != nil {
+		t.Fatalf("Post() returned error: %v", err)
+	}
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Fatalf("middleware order = %v, want [outer inner]", order)
+	}
+	if seen.Operation != "Sale" || seen.Path != "/sale" || seen.HTTPResponse == nil || seen.HTTPResponse.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected call seen by middleware: %+v", seen)
+	}
+	if body, _ := io.ReadAll(seen.HTTPResponse.Body); len(body) == 0 {
+		t.Fatal("middleware could not read response body")
+	}
+}
+
+func TestClientMiddlewareShortCircuit(t *testing.T) {
+	client := newTestClient("http://127.0.0.1:1")
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) error {
+			call.Response.(*testResponse).Value = "stubbed"
+			return nil
+		}
+	})
+
+	resp := &testResponse{}
+	if err := client.Get(context.Background(), "/query", nil, resp, nil); err != nil {
+		t.Fatalf("Get() returned error: %v", err)
+	}
+	if resp.Value != "stubbed" {
+		t.Fatalf("Value = %q, want %q", resp.Value, "stubbed")
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+// Call describes a single API call as seen by middleware
+type Call struct {
+	// Operation is the logical API operation name, see constant.Operation* (empty if not provided)
+	Operation string
+
+	// Path is the API path, e.g. constant.PathSale
+	Path string
+
+	// Request is the typed SDK request model, e.g. *request.SaleRequest
+	Request interface{}
+
+	// Response is the typed SDK response model the result is decoded into, e.g. *response.SaleResponse
+	Response interface{}
+
+	// Options are the per-call options, nil if none were given
+	Options *RequestOptions
+
+	// HTTPRequest is the outgoing HTTP request. Middleware may modify it before calling next;
+	// the modified request is used for every attempt
+	HTTPRequest *http.Request
+
+	// HTTPResponse is the HTTP response of the last attempt, available after next returns
+	// It is nil if no response was received. Its body is an in-memory copy that can be read again
+	HTTPResponse *http.Response
+}
+
+// Handler executes a call and returns the resulting error
+type Handler func(ctx context.Context, call *Call) error
+
+// Middleware wraps a Handler to run code around every call, e.g. to add headers, record latency
+// or short-circuit the call in tests by filling call.Response without calling next
+type Middleware func(next Handler) Handler
+
+// AttemptGate is called before every attempt of a call, including retries, e.g. to wait for a rate limiter
+// A returned error ends the call without sending the attempt. The call fails with that error, or with
+// the failure of the previous attempt when one was already sent, since its outcome is still unknown
+type AttemptGate func(ctx context.Context, call *Call) error
+
+// newCall creates the call passed through the middleware chain
+func newCall(path string, request, response interface{}, req *http.Request, opts *RequestOptions) *Call {
+	call := &Call{
+		Path:        path,
+		Request:     request,
+		Response:    response,
+		HTTPRequest: req,
+		Options:     opts,
+	}
+	if opts != nil {
+		call.Operation = opts.Operation
+	}
+	return call
+}
+
+// chain wraps handler with middlewares, the first middleware being the outermost
+func chain(middlewares []Middleware, handler Handler) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
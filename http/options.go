@@ -0,0 +1,64 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+)
+
+// RequestOptions holds per-request settings that override the client defaults
+type RequestOptions struct {
+	// Operation is the logical API operation name, see constant.Operation*
+	// It selects the per-operation timeout profile
+	Operation string
+
+	// Timeout overrides the timeout of each attempt when positive
+	Timeout time.Duration
+
+	// ClientRequestID overrides the generated X-Client-Request-Id header when not empty
+	ClientRequestID string
+
+	// Header holds extra headers added to the request
+	Header http.Header
+
+	// MaxRetries caps the number of retries after the initial attempt when not nil (0 disables retries)
+	MaxRetries *int
+
+	// RetryPost overrides whether a POST request may be retried when not nil
+	RetryPost *bool
+
+	// APIKey overrides the client API key when not empty
+	APIKey string
+}
+
+// applyRequestOptions applies the per-request header overrides
+// Explicit options take precedence over extra headers with the same name
+func applyRequestOptions(req *http.Request, opts *RequestOptions) {
+	if opts == nil {
+		return
+	}
+	for name, values := range opts.Header {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if opts.ClientRequestID != "" {
+		req.Header.Set(headerRequestID, opts.ClientRequestID)
+	}
+	if opts.APIKey != "" {
+		req.Header.Set(headerAuthorization, constant.AuthorizationBearerPrefix+opts.APIKey)
+	}
+}
+
+// maxRetriesPolicy caps the retries of another policy
+type maxRetriesPolicy struct {
+	RetryPolicy
+	maxRetries int
+}
+
+// ShouldRetry implements RetryPolicy
+func (p maxRetriesPolicy) ShouldRetry(state *RetryState) bool {
+	return state.Attempt <= p.maxRetries && p.RetryPolicy.ShouldRetry(state)
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+)
+
+const (
+	defaultRetryBaseDelay  = 1 * time.Second
+	defaultRetryMaxDelay   = 30 * time.Second
+	defaultRetryMaxElapsed = 2 * time.Minute
+)
+
+// RetryState describes the attempt that just failed
+type RetryState struct {
+	// Attempt is the number of attempts made so far, starting at 1
+	Attempt int
+
+	// Elapsed is the time since the first attempt started
+	Elapsed time.Duration
+
+	// Method is the HTTP method of the request
+	Method string
+
+	// StatusCode is the HTTP status code of the response, 0 if no response was received
+	StatusCode int
+
+	// Header is the HTTP response header, nil if no response was received
+	Header http.Header
+
+	// Err is the error returned by the attempt
+	Err error
+}
+
+// RetryPolicy decides whether a failed attempt is retried and how long to wait before the next attempt
+// Implementations must be safe for concurrent use
+type RetryPolicy interface {
+	// ShouldRetry reports whether another attempt should be made
+	ShouldRetry(state *RetryState) bool
+
+	// Delay returns how long to wait before the next attempt
+	Delay(state *RetryState) time.Duration
+}
+
+// ExponentialBackoff is the default RetryPolicy
+// It retries network failures, HTTP 429 and HTTP 5xx responses with exponentially growing,
+// fully jittered delays, and honors the Retry-After header on HTTP 429 and 503 responses
+type ExponentialBackoff struct {
+	// MaxRetries is the maximum number of retries after the initial attempt
+	MaxRetries int
+
+	// BaseDelay is the upper bound of the first retry delay; the bound doubles on each retry
+	BaseDelay time.Duration
+
+	// MaxDelay caps the upper bound of any single retry delay
+	MaxDelay time.Duration
+
+	// MaxElapsed caps the total time spent on a call including retries (0 means no cap)
+	MaxElapsed time.Duration
+}
+
+// NewExponentialBackoff creates an ExponentialBackoff with default delays
+func NewExponentialBackoff(maxRetries int) *ExponentialBackoff {
+	return &ExponentialBackoff{
+		MaxRetries: maxRetries,
+		BaseDelay:  defaultRetryBaseDelay,
+		MaxDelay:   defaultRetryMaxDelay,
+		MaxElapsed: defaultRetryMaxElapsed,
+	}
+}
+
+// ShouldRetry implements RetryPolicy
+func (p *ExponentialBackoff) ShouldRetry(state *RetryState) bool {
+	if state.Attempt > p.MaxRetries {
+		return false
+	}
+	if p.MaxElapsed > 0 {
+		remaining := p.MaxElapsed - state.Elapsed
+		if remaining <= 0 {
+			return false
+		}
+		// Do not wait past the budget when the server asks for a longer pause
+		if retryAfter, ok := retryAfterDelay(state); ok && retryAfter > remaining {
+			return false
+		}
+	}
+
+	if state.StatusCode == http.StatusTooManyRequests || state.StatusCode >= http.StatusInternalServerError {
+		return true
+	}
+	if netErr, ok := state.Err.(*errors.NetworkError); ok {
+		return netErr.IsRetryable()
+	}
+	return false
+}
+
+// Delay implements RetryPolicy
+func (p *ExponentialBackoff) Delay(state *RetryState) time.Duration {
+	if retryAfter, ok := retryAfterDelay(state); ok {
+		return retryAfter
+	}
+
+	ceiling := p.BaseDelay
+	for i := 1; i < state.Attempt && (p.MaxDelay <= 0 || ceiling < p.MaxDelay); i++ {
+		ceiling *= 2
+	}
+	if p.MaxDelay > 0 && ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if p.MaxElapsed > 0 {
+		if remaining := p.MaxElapsed - state.Elapsed; ceiling > remaining {
+			ceiling = remaining
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return jitter(ceiling)
+}
+
+// retryAfterDelay parses the Retry-After header of HTTP 429 and 503 responses
+// Both the delay-seconds and the HTTP-date forms are supported
+func retryAfterDelay(state *RetryState) (time.Duration, bool) {
+	if state.StatusCode != http.StatusTooManyRequests && state.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	value := state.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		delay := time.Until(at)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// jitter returns a random duration in [0, ceiling)
+func jitter(ceiling time.Duration) time.Duration {
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return time.Duration(jitterRand.Int63n(int64(ceiling)))
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+)
+
+func TestExponentialBackoffShouldRetry(t *testing.T) {
+	policy := NewExponentialBackoff(2)
+	retryable := errors.NewNetworkError("boom", true, nil)
+	permanent := errors.NewNetworkError("bad json", false, nil)
+
+	cases := []struct {
+		name  string
+		state RetryState
+		want  bool
+	}{
+		{"network error", RetryState{Attempt: 1, Err: retryable}, true},
+		{"non-retryable error", RetryState{Attempt: 1, StatusCode: 200, Err: permanent}, false},
+		{"too many requests", RetryState{Attempt: 1, StatusCode: 429, Err: permanent}, true},
+		{"server error", RetryState{Attempt: 2, StatusCode: 502, Err: retryable}, true},
+		{"client error", RetryState{Attempt: 1, StatusCode: 400, Err: permanent}, false},
+		{"retries exhausted", RetryState{Attempt: 3, Err: retryable}, false},
+		{"elapsed budget exhausted", RetryState{Attempt: 1, Elapsed: 3 * time.Minute, Err: retryable}, false},
+		{
+			"retry-after beyond budget",
+			RetryState{Attempt: 1, StatusCode: 503, Header: http.Header{"Retry-After": []string{"600"}}, Err: retryable},
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		state := tc.state
+		if got := policy.ShouldRetry(&state); got != tc.want {
+			t.Fatalf("%s: ShouldRetry() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExponentialBackoffDelay(t *testing.T) {
+	policy := &ExponentialBackoff{MaxRetries: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		ceiling := 100 * time.Millisecond << uint(attempt-1)
+		if ceiling > time.Second {
+			ceiling = time.Second
+		}
+		delay := policy.Delay(&RetryState{Attempt: attempt})
+		if delay < 0 || delay >= ceiling {
+			t.Fatalf("attempt %d: Delay() = %v, want [0, %v)", attempt, delay, ceiling)
+		}
+	}
+
+	state := &RetryState{Attempt: 1, StatusCode: 429, Header: http.Header{"Retry-After": []string{"7"}}}
+	if delay := policy.Delay(state); delay != 7*time.Second {
+		t.Fatalf("Delay() with Retry-After = %v, want 7s", delay)
+	}
+}
@@ -0,0 +1,161 @@
+package nexus
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+)
+
+// postTransaction executes a money-moving POST request
+// When IdempotentRetry is enabled and the request carries a TransactionRequestID, failed attempts are
+// retried with the identical body. If the request still fails with a network error after all retries,
+// the outcome is ambiguous: the transaction may or may not have been created. In that case the transaction
+// is queried by its TransactionRequestID and, if found, resp is populated from the query result
+func (c *NexusClient) postTransaction(ctx context.Context, path string, req interface{}, resp interface{}, appID, merchantID, transactionRequestID string, opts *http.RequestOptions) (err error) {
+	idempotentRetry := c.idempotentRetry
+	if opts == nil {
+		opts = &http.RequestOptions{}
+	}
+	if opts.RetryPost != nil {
+		idempotentRetry = *opts.RetryPost
+	}
+	// Without an idempotency key a resent request could be processed twice
+	idempotentRetry = idempotentRetry && transactionRequestID != ""
+	opts.RetryPost = &idempotentRetry
+
+	entry, err := c.journalBegin(ctx, path, req, opts)
+	if err != nil {
+		return err
+	}
+	defer func() { c.journalComplete(entry, resp, err) }()
+
+	err = c.httpClient.Post(ctx, path, req, resp, opts)
+	if err == nil || !idempotentRetry {
+		return err
+	}
+	netErr, ok := err.(*errors.NetworkError)
+	if !ok || ctx.Err() != nil {
+		return err
+	}
+
+	// The query uses the same credentials and extra headers as the original call
+	queryOpts := &http.RequestOptions{Operation: constant.OperationQuery}
+	if opts != nil {
+		queryOpts.APIKey = opts.APIKey
+		queryOpts.Header = opts.Header
+	}
+
+	queryResp := &response.QueryResponse{}
+	queryErr := c.httpClient.Get(ctx, constant.PathQuery, &request.QueryRequest{
+		AppID:                appID,
+		MerchantID:           merchantID,
+		TransactionRequestID: transactionRequestID,
+	}, queryResp, queryOpts)
+	if queryErr != nil {
+		if c.IsTransactionNotFound(queryErr) {
+			// The transaction does not exist, so the request never took effect and can be safely retried
+			return errors.NewNetworkError(
+				fmt.Sprintf("Request failed and no transaction found for transactionRequestId '%s'", transactionRequestID),
+				true,
+				err,
+			)
+		}
+		// Reconciliation itself failed (e.g. rejected credentials or throttling); the outcome remains unknown
+		return err
+	}
+
+	fillFromQuery(resp, queryResp, netErr.Meta())
+	return nil
+}
+
+// outcomeUnknown reports whether a failed call may still have taken effect: a network error, or ctx
+// ending while the request may have been in flight. Business errors, open circuits and local
+// validation failures mean the request was not processed
+func outcomeUnknown(err error) bool {
+	if _, ok := err.(*errors.NetworkError); ok {
+		return true
+	}
+	return stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded)
+}
+
+// fillFromQuery populates a transaction response from the query result of the same transaction
+// meta is the HTTP metadata of the original call, kept instead of the query's
+func fillFromQuery(resp interface{}, q *response.QueryResponse, meta *common.ResponseMeta) {
+	status := string(q.TransactionStatus)
+	base := q.BaseResponse
+	base.Meta = meta
+	switch r := resp.(type) {
+	case *response.SaleResponse:
+		r.BaseResponse = base
+		r.TransactionID = q.TransactionID
+		r.ReferenceOrderID = q.ReferenceOrderID
+		r.TransactionRequestID = q.TransactionRequestID
+		r.TransactionStatus = status
+	case *response.AuthResponse:
+		r.BaseResponse = base
+		r.TransactionID = q.TransactionID
+		r.ReferenceOrderID = q.ReferenceOrderID
+		r.TransactionRequestID = q.TransactionRequestID
+		r.TransactionStatus = status
+	case *response.ForcedAuthResponse:
+		r.BaseResponse = base
+		r.TransactionID = q.TransactionID
+		r.ReferenceOrderID = q.ReferenceOrderID
+		r.TransactionRequestID = q.TransactionRequestID
+		r.TransactionStatus = status
+	case *response.IncrementalAuthResponse:
+		r.BaseResponse = base
+		r.TransactionID = q.TransactionID
+		r.TransactionRequestID = q.TransactionRequestID
+		r.TransactionStatus = status
+	case *response.PostAuthResponse:
+		r.BaseResponse = base
+		r.TransactionID = q.TransactionID
+		r.TransactionRequestID = q.TransactionRequestID
+		r.TransactionStatus = status
+	case *response.RefundResponse:
+		r.BaseResponse = base
+		r.TransactionID = q.TransactionID
+		r.ReferenceOrderID = q.ReferenceOrderID
+		r.TransactionRequestID = q.TransactionRequestID
+		r.TransactionStatus = status
+	case *response.VoidResponse:
+		r.BaseResponse = base
+		r.TransactionID = q.TransactionID
+		r.TransactionRequestID = q.TransactionRequestID
+		r.TransactionStatus = status
+	case *response.CheckoutDirectSaleResponse:
+		r.BaseResponse = base
+		r.TransactionID = q.TransactionID
+		r.ReferenceOrderID = q.ReferenceOrderID
+		r.TransactionRequestID = q.TransactionRequestID
+	case *response.OnlineRefundResponse:
+		r.BaseResponse = base
+		r.TransactionID = q.TransactionID
+		r.TransactionRequestID = q.TransactionRequestID
+		r.TransactionStatus = status
+		r.TransactionType = string(q.TransactionType)
+		r.CreateTime = q.CreateTime
+		r.CompleteTime = q.CompleteTime
+		r.TransactionResultCode = q.TransactionResultCode
+		r.TransactionResultMsg = q.TransactionResultMsg
+		r.Description = q.Description
+		if q.Amount != nil {
+			r.Amount = &common.OnlineRefundAmount{
+				PriceCurrency:   q.Amount.PriceCurrency,
+				TotalAmount:     q.Amount.TransAmount,
+				OrderAmount:     q.Amount.OrderAmount,
+				TaxAmount:       q.Amount.TaxAmount,
+				SurchargeAmount: q.Amount.SurchargeAmount,
+				TipAmount:       q.Amount.TipAmount,
+			}
+		}
+	}
+}
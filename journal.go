@@ -0,0 +1,160 @@
+package nexus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/journal"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/util"
+)
+
+// journalCompleteTimeout bounds the write recording a request's outcome, made after the caller's
+// context may have ended
+const journalCompleteTimeout = 5 * time.Second
+
+// queryableOperations are the operations whose transaction can be found by Query with its TransactionRequestID
+var queryableOperations = map[string]bool{
+	constant.OperationSale:            true,
+	constant.OperationAuth:            true,
+	constant.OperationForcedAuth:      true,
+	constant.OperationIncrementalAuth: true,
+	constant.OperationPostAuth:        true,
+	constant.OperationRefund:          true,
+	constant.OperationVoid:            true,
+	constant.OperationDirectPayment:   true,
+	constant.OperationOnlineRefund:    true,
+}
+
+// post executes a POST request that is not reconciled by Query, journaled like postTransaction
+// It is never retried: not every such request carries an idempotency key, so IdempotentRetry does not apply
+func (c *NexusClient) post(ctx context.Context, path string, req interface{}, resp interface{}, opts *http.RequestOptions) (err error) {
+	if opts != nil {
+		opts.RetryPost = nil
+	}
+	entry, err := c.journalBegin(ctx, path, req, opts)
+	if err != nil {
+		return err
+	}
+	defer func() { c.journalComplete(entry, resp, err) }()
+
+	return c.httpClient.Post(ctx, path, req, resp, opts)
+}
+
+// journalBegin writes the journal entry of a request about to be sent, nil when no journal is configured
+func (c *NexusClient) journalBegin(ctx context.Context, path string, req interface{}, opts *http.RequestOptions) (*journal.Entry, error) {
+	if c.journal == nil {
+		return nil, nil
+	}
+	payload, err := journal.Sanitize(req)
+	if err != nil {
+		return nil, fmt.Errorf("journal request: %w", err)
+	}
+	entry := &journal.Entry{
+		ID:                   util.GenerateUUID(),
+		Path:                 path,
+		AppID:                requestField(req, "AppID"),
+		MerchantID:           requestField(req, "MerchantID"),
+		TerminalSN:           requestField(req, "TerminalSN"),
+		TransactionRequestID: requestField(req, "TransactionRequestID"),
+		Payload:              payload,
+		Status:               journal.StatusPending,
+		StartedAt:            time.Now(),
+	}
+	if opts != nil {
+		entry.Operation = opts.Operation
+	}
+	if err := c.journal.Begin(ctx, entry); err != nil {
+		// Sending without a journal entry would defeat crash recovery
+		return nil, fmt.Errorf("journal request, not sent: %w", err)
+	}
+	return entry, nil
+}
+
+// journalComplete records the outcome of a journaled request
+// Only errors leaving the outcome unknown (see outcomeUnknown) are recorded as unknown; business errors,
+// open circuits and local failures mean the request was not processed and are recorded as rejected.
+// The write uses its own context so a cancelled call is still recorded. A failed write is logged only;
+// the entry stays unfinished and Recover resolves it later
+func (c *NexusClient) journalComplete(entry *journal.Entry, resp interface{}, err error) {
+	if entry == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		entry.Status = journal.StatusSucceeded
+		entry.TransactionID = requestField(resp, "TransactionID")
+		entry.TransactionStatus = requestField(resp, "TransactionStatus")
+	case outcomeUnknown(err):
+		entry.Status = journal.StatusUnknown
+		entry.Error = err.Error()
+	default:
+		entry.Status = journal.StatusRejected
+		entry.Error = err.Error()
+	}
+	entry.CompletedAt = time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), journalCompleteTimeout)
+	defer cancel()
+	if err := c.journal.Complete(ctx, entry); err != nil {
+		c.logger.Warnf("Journal write failed - Operation: %s, TransactionRequestID: %s: %v", entry.Operation, entry.TransactionRequestID, err)
+	}
+}
+
+// Recover resolves the journal entries left unfinished by a crash or an ambiguous failure
+// Each entry is looked up by Query with its TransactionRequestID: a found transaction marks the entry
+// succeeded with the transaction's current status, a transaction that does not exist marks it rejected.
+// Entries that cannot be queried (e.g. Abort, TipAdjust, BatchClose) are marked abandoned for manual checking.
+// Entries whose query fails otherwise stay unfinished, and the last such error is returned.
+// The processed entries are returned in journal order
+func (c *NexusClient) Recover(ctx context.Context, opts ...CallOption) ([]*journal.Entry, error) {
+	if c.journal == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"Journal is not configured",
+			"",
+		)
+	}
+	entries, err := c.journal.Unfinished(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return entries, ctx.Err()
+		}
+		if !queryableOperations[entry.Operation] || entry.TransactionRequestID == "" {
+			entry.Status = journal.StatusAbandoned
+		} else {
+			q, err := c.Query(ctx, &request.QueryRequest{
+				AppID:                entry.AppID,
+				MerchantID:           entry.MerchantID,
+				TransactionRequestID: entry.TransactionRequestID,
+			}, opts...)
+			switch {
+			case err == nil:
+				entry.Status = journal.StatusSucceeded
+				entry.TransactionID = q.TransactionID
+				entry.TransactionStatus = string(q.TransactionStatus)
+				entry.Error = ""
+			case c.IsTransactionNotFound(err):
+				entry.Status = journal.StatusRejected
+				entry.Error = err.Error()
+			default:
+				c.logger.Warnf("Journal recovery query failed - TransactionRequestID: %s: %v", entry.TransactionRequestID, err)
+				lastErr = err
+				continue
+			}
+		}
+		entry.CompletedAt = time.Now()
+		if err := c.journal.Complete(ctx, entry); err != nil {
+			lastErr = err
+		}
+	}
+	return entries, lastErr
+}
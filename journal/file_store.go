@@ -0,0 +1,205 @@
+package journal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+)
+
+// FileStoreConfig holds the configuration for creating a FileStore
+type FileStoreConfig struct {
+	// Path is the journal file, created if missing (required)
+	Path string
+
+	// NoSync skips the fsync after each write (optional, defaults to false)
+	// Faster, but entries may be lost if the machine crashes rather than only the process
+	NoSync bool
+}
+
+// FileStore is a Store appending entries as JSON lines to a local file
+// Each write appends the whole entry; when reading, the last line of an entry wins. Compact rewrites
+// the file keeping only unfinished entries. It is safe for concurrent use within one process
+type FileStore struct {
+	mu     sync.Mutex
+	path   string
+	noSync bool
+	file   *os.File
+}
+
+// NewFileStore opens or creates a journal file
+func NewFileStore(config *FileStoreConfig) (*FileStore, error) {
+	if config == nil || config.Path == "" {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"FileStoreConfig with Path cannot be nil",
+			"",
+		)
+	}
+	file, err := os.OpenFile(config.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	if err := terminateLastLine(config.Path, file); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &FileStore{path: config.Path, noSync: config.NoSync, file: file}, nil
+}
+
+// terminateLastLine ends a line torn by a crash so the next entry starts on its own line
+func terminateLastLine(path string, file *os.File) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	reader, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	defer reader.Close()
+	last := make([]byte, 1)
+	if _, err := reader.ReadAt(last, info.Size()-1); err != nil {
+		return fmt.Errorf("read journal: %w", err)
+	}
+	if last[0] != '\n' {
+		if _, err := file.Write([]byte{'\n'}); err != nil {
+			return fmt.Errorf("write journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// Begin appends a new entry
+func (s *FileStore) Begin(ctx context.Context, entry *Entry) error {
+	return s.append(entry)
+}
+
+// Complete appends the finished entry
+func (s *FileStore) Complete(ctx context.Context, entry *Entry) error {
+	return s.append(entry)
+}
+
+// Unfinished returns the entries whose last line is pending or unknown, oldest first
+func (s *FileStore) Unfinished(ctx context.Context) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unfinished()
+}
+
+// Compact rewrites the journal keeping only unfinished entries
+// The new file replaces the old one atomically, so a crash during Compact loses nothing
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.unfinished()
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("compact journal: %w", err)
+	}
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err = encoder.Encode(entry); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, s.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("compact journal: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("reopen journal: %w", err)
+	}
+	_ = s.file.Close()
+	s.file = file
+	return nil
+}
+
+// Close closes the journal file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// append writes one entry as a JSON line
+func (s *FileStore) append(entry *Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode journal entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	if !s.noSync {
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("sync journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// unfinished reads the journal; s.mu must be held
+func (s *FileStore) unfinished() ([]*Entry, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	defer file.Close()
+
+	latest := make(map[string]*Entry)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		entry := &Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			// A line torn by a crash while writing; an earlier line of the same entry, if any, still applies
+			continue
+		}
+		latest[entry.ID] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+
+	entries := make([]*Entry, 0)
+	for _, entry := range latest {
+		if !entry.Finished() {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].StartedAt.Before(entries[j].StartedAt)
+	})
+	return entries, nil
+}
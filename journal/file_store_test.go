@@ -0,0 +1,87 @@
+package journal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileStoreUnfinished(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	store, err := NewFileStore(&FileStoreConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewFileStore() returned error: %v", err)
+	}
+	ctx := context.Background()
+	start := time.Now()
+
+	done := &Entry{ID: "1", Operation: "Sale", Status: StatusPending, StartedAt: start}
+	pending := &Entry{ID: "2", Operation: "Sale", Status: StatusPending, StartedAt: start.Add(time.Second)}
+	unknown := &Entry{ID: "3", Operation: "Refund", Status: StatusPending, StartedAt: start.Add(2 * time.Second)}
+	for _, e := range []*Entry{done, pending, unknown} {
+		if err := store.Begin(ctx, e); err != nil {
+			t.Fatalf("Begin() returned error: %v", err)
+		}
+	}
+	done.Status = StatusSucceeded
+	unknown.Status = StatusUnknown
+	_ = store.Complete(ctx, done)
+	_ = store.Complete(ctx, unknown)
+	_ = store.Close()
+
+	// A crash while writing leaves a torn line, which a reopened store must not merge into the next entry
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	_, _ = file.WriteString(`{"id":"4","operation":"Sa`)
+	_ = file.Close()
+	store, err = NewFileStore(&FileStoreConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewFileStore() returned error: %v", err)
+	}
+	defer store.Close()
+	_ = store.Begin(ctx, &Entry{ID: "5", Operation: "Void", Status: StatusPending, StartedAt: start.Add(3 * time.Second)})
+
+	entries, err := store.Unfinished(ctx)
+	if err != nil {
+		t.Fatalf("Unfinished() returned error: %v", err)
+	}
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	if got := strings.Join(ids, ","); got != "2,3,5" {
+		t.Fatalf("Unfinished() = %s, want 2,3,5", got)
+	}
+
+	if err := store.Compact(); err != nil {
+		t.Fatalf("Compact() returned error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Fatalf("journal has %d lines after Compact(), want 3", lines)
+	}
+	if entries, _ := store.Unfinished(ctx); len(entries) != 3 {
+		t.Fatalf("Unfinished() after Compact() returned %d entries, want 3", len(entries))
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	payload, err := Sanitize(map[string]interface{}{
+		"transactionRequestId": "REQ_1",
+		"cardEncryptedData":    "secret",
+		"customerEmail":        "a@example.com",
+		"amount":               map[string]interface{}{"orderAmount": int64(9007199254740993)},
+	})
+	if err != nil {
+		t.Fatalf("Sanitize() returned error: %v", err)
+	}
+	got := string(payload)
+	if strings.Contains(got, "secret") || strings.Contains(got, "a@example.com") {
+		t.Fatalf("Sanitize() = %s, want card data and email redacted", got)
+	}
+	if !strings.Contains(got, `"REQ_1"`) || !strings.Contains(got, "9007199254740993") {
+		t.Fatalf("Sanitize() = %s, want other fields unchanged", got)
+	}
+}
@@ -0,0 +1,112 @@
+// Package journal provides a write-ahead journal of the SDK's POST requests
+//
+// An entry is written before a request is sent and updated with its outcome afterwards, so a process
+// that crashes in between can find the requests whose outcome it never learned and resolve them
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/util"
+)
+
+// Status is the state of a journal entry
+type Status string
+
+const (
+	// StatusPending means the request is being sent, or the process stopped before its outcome was recorded
+	StatusPending Status = "PENDING"
+
+	// StatusSucceeded means the API accepted the request
+	StatusSucceeded Status = "SUCCEEDED"
+
+	// StatusRejected means the API rejected the request, it was never sent (e.g. an open circuit) or
+	// the transaction was never created
+	StatusRejected Status = "REJECTED"
+
+	// StatusUnknown means the request failed in a way that leaves its outcome unknown, e.g. a network error
+	StatusUnknown Status = "UNKNOWN"
+
+	// StatusAbandoned means the outcome was unknown and cannot be resolved by Query; check it manually
+	StatusAbandoned Status = "ABANDONED"
+)
+
+// String returns the status code
+func (s Status) String() string {
+	return string(s)
+}
+
+// Entry is a journaled request
+type Entry struct {
+	// ID identifies the entry
+	ID string `json:"id"`
+
+	// Operation is the API operation, see constant.Operation*
+	Operation string `json:"operation"`
+
+	// Path is the API path
+	Path string `json:"path"`
+
+	// AppID is the application ID of the request
+	AppID string `json:"appId,omitempty"`
+
+	// MerchantID is the merchant ID of the request
+	MerchantID string `json:"merchantId,omitempty"`
+
+	// TerminalSN is the terminal serial number of the request
+	TerminalSN string `json:"terminalSn,omitempty"`
+
+	// TransactionRequestID is the request ID of the request, used to resolve it by Query
+	TransactionRequestID string `json:"transactionRequestId,omitempty"`
+
+	// Payload is the request body with sensitive fields redacted, see Sanitize
+	Payload json.RawMessage `json:"payload,omitempty"`
+
+	// Status is the state of the entry
+	Status Status `json:"status"`
+
+	// TransactionID is the SUNBAY Nexus transaction ID, when known
+	TransactionID string `json:"transactionId,omitempty"`
+
+	// TransactionStatus is the transaction status reported by the response or by Query, when known
+	TransactionStatus string `json:"transactionStatus,omitempty"`
+
+	// Error is the error of the request, when it failed
+	Error string `json:"error,omitempty"`
+
+	// StartedAt is when the entry was written
+	StartedAt time.Time `json:"startedAt"`
+
+	// CompletedAt is when the outcome was recorded, zero while unfinished
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// Finished reports whether the outcome of the entry is known
+func (e *Entry) Finished() bool {
+	return e.Status != StatusPending && e.Status != StatusUnknown
+}
+
+// Store persists journal entries
+// Implementations must be safe for concurrent use and should make Begin durable before returning,
+// since the request is sent right after
+type Store interface {
+	// Begin records an entry before its request is sent
+	Begin(ctx context.Context, entry *Entry) error
+
+	// Complete records the outcome of an entry written by Begin
+	Complete(ctx context.Context, entry *Entry) error
+
+	// Unfinished returns the entries whose outcome is not known, oldest first
+	Unfinished(ctx context.Context) ([]*Entry, error)
+}
+
+// Sanitize returns the JSON form of a request model with card data and customer details redacted
+func Sanitize(req interface{}) (json.RawMessage, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return util.RedactJSON(body)
+}
@@ -0,0 +1,141 @@
+package nexus
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	nexushttp "github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/journal"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+)
+
+func TestJournalRecordsAndRecovers(t *testing.T) {
+	store, err := journal.NewFileStore(&journal.FileStoreConfig{Path: filepath.Join(t.TempDir(), "journal.log")})
+	if err != nil {
+		t.Fatalf("NewFileStore() returned error: %v", err)
+	}
+	defer store.Close()
+
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathSale:
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionStatus":"P"}}`))
+		case constant.PathRefund:
+			_, _ = w.Write([]byte(`{"code":"E01","msg":"declined"}`))
+		case constant.PathQuery:
+			switch r.URL.Query().Get("transactionRequestId") {
+			case "CRASHED":
+				_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_2","transactionStatus":"S"}}`))
+			default:
+				_, _ = w.Write([]byte(`{"code":"T404","msg":"transaction not found"}`))
+			}
+		}
+	}, Config{Journal: store, TransactionNotFoundCodes: []string{"T404"}})
+	ctx := context.Background()
+
+	if _, err := client.Sale(ctx, &request.SaleRequest{TransactionRequestID: "SALE_1"}); err != nil {
+		t.Fatalf("Sale() returned error: %v", err)
+	}
+	if _, err := client.Refund(ctx, &request.RefundRequest{TransactionRequestID: "REFUND_1"}); err == nil {
+		t.Fatal("Refund() should return the business error")
+	}
+	if entries, _ := store.Unfinished(ctx); len(entries) != 0 {
+		t.Fatalf("Unfinished() = %d entries after completed calls, want 0", len(entries))
+	}
+
+	// Entries a crash left pending before the response arrived
+	_ = store.Begin(ctx, &journal.Entry{ID: "a", Operation: constant.OperationSale, TransactionRequestID: "CRASHED", Status: journal.StatusPending, StartedAt: time.Now()})
+	_ = store.Begin(ctx, &journal.Entry{ID: "b", Operation: constant.OperationSale, TransactionRequestID: "NEVER_SENT", Status: journal.StatusPending, StartedAt: time.Now().Add(time.Millisecond)})
+	_ = store.Begin(ctx, &journal.Entry{ID: "c", Operation: constant.OperationAbort, Status: journal.StatusPending, StartedAt: time.Now().Add(2 * time.Millisecond)})
+
+	entries, err := client.Recover(ctx)
+	if err != nil {
+		t.Fatalf("Recover() returned error: %v", err)
+	}
+	want := []journal.Status{journal.StatusSucceeded, journal.StatusRejected, journal.StatusAbandoned}
+	if len(entries) != len(want) {
+		t.Fatalf("Recover() returned %d entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		if e.Status != want[i] {
+			t.Fatalf("entry %s status = %s, want %s", e.ID, e.Status, want[i])
+		}
+	}
+	if entries[0].TransactionID != "TX_2" || entries[0].TransactionStatus != "S" {
+		t.Fatalf("recovered entry = %+v, want TX_2 with status S", entries[0])
+	}
+	if remaining, _ := store.Unfinished(ctx); len(remaining) != 0 {
+		t.Fatalf("Unfinished() after Recover() = %d entries, want 0", len(remaining))
+	}
+}
+
+// liveContextStore fails Complete when called with an ended context
+type liveContextStore struct {
+	*journal.FileStore
+}
+
+func (s liveContextStore) Complete(ctx context.Context, entry *journal.Entry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.FileStore.Complete(ctx, entry)
+}
+
+func TestJournalRecordsOutcomeOfFailedCalls(t *testing.T) {
+	store, err := journal.NewFileStore(&journal.FileStoreConfig{Path: filepath.Join(t.TempDir(), "journal.log")})
+	if err != nil {
+		t.Fatalf("NewFileStore() returned error: %v", err)
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathSale:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case constant.PathAuth:
+			cancel()
+			time.Sleep(10 * time.Millisecond)
+		case constant.PathQuery:
+			_, _ = w.Write([]byte(`{"code":"A401","msg":"unauthorized"}`))
+		}
+	}, Config{
+		Journal:        liveContextStore{store},
+		CircuitBreaker: &nexushttp.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute},
+	})
+
+	if _, err := client.Sale(context.Background(), &request.SaleRequest{TransactionRequestID: "SALE_1"}, WithMaxRetries(0)); err == nil {
+		t.Fatal("Sale() should return the network error")
+	}
+	if _, err := client.Sale(context.Background(), &request.SaleRequest{TransactionRequestID: "SALE_2"}); err == nil {
+		t.Fatal("Sale() should fail while the circuit is open")
+	}
+	if _, err := client.Auth(ctx, &request.AuthRequest{TransactionRequestID: "AUTH_1"}); err == nil {
+		t.Fatal("Auth() should return the context error")
+	}
+
+	entries, err := store.Unfinished(context.Background())
+	if err != nil {
+		t.Fatalf("Unfinished() returned error: %v", err)
+	}
+	got := map[string]journal.Status{}
+	for _, e := range entries {
+		got[e.TransactionRequestID] = e.Status
+	}
+	if len(got) != 2 || got["SALE_1"] != journal.StatusUnknown || got["AUTH_1"] != journal.StatusUnknown {
+		t.Fatalf("unfinished entries = %v, want SALE_1 and AUTH_1 unknown", got)
+	}
+
+	// A query error other than not found leaves the entries unfinished
+	if _, err := client.Recover(context.Background()); err == nil {
+		t.Fatal("Recover() should return the query error")
+	}
+	if remaining, _ := store.Unfinished(context.Background()); len(remaining) != 2 {
+		t.Fatalf("Unfinished() after Recover() = %d entries, want 2", len(remaining))
+	}
+}
@@ -0,0 +1,175 @@
+// Package lifecycle models the legal state changes of a Nexus transaction
+//
+// A transaction moves from I (initial) through P (processing) to one of the final statuses S, F or C.
+// Only a successful transaction changes afterwards, through its types.RelatedTransactionStatus when it is
+// voided, refunded, captured or incrementally authorized
+package lifecycle
+
+import (
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+// State is the lifecycle state of a transaction
+type State struct {
+	// Status is the transaction status
+	Status types.TransactionStatus
+
+	// Related is the change caused by subsequent transactions, empty when there is none
+	Related types.RelatedTransactionStatus
+}
+
+// StateOf returns the state reported by a query result or notification
+func StateOf(resp *response.QueryResponse) State {
+	if resp == nil {
+		return State{}
+	}
+	return State{Status: resp.TransactionStatus, Related: resp.RelatedTransactionStatus}
+}
+
+// String returns the status code, followed by the related status when set (e.g. "S/REFUNDED")
+func (s State) String() string {
+	if s.Related == "" {
+		return string(s.Status)
+	}
+	return string(s.Status) + "/" + string(s.Related)
+}
+
+// IsValid checks if the state can occur: known codes, and a related status only on a successful transaction
+func (s State) IsValid() bool {
+	if !s.Status.IsValid() {
+		return false
+	}
+	if s.Related == "" {
+		return true
+	}
+	return s.Related.IsValid() && s.Status == types.TransactionStatusSuccess
+}
+
+// statusTransitions lists the statuses each non-final status can move to
+var statusTransitions = map[types.TransactionStatus][]types.TransactionStatus{
+	types.TransactionStatusInitial: {
+		types.TransactionStatusProcessing, types.TransactionStatusSuccess,
+		types.TransactionStatusFail, types.TransactionStatusClosed,
+	},
+	types.TransactionStatusProcessing: {
+		types.TransactionStatusSuccess, types.TransactionStatusFail, types.TransactionStatusClosed,
+	},
+}
+
+// relatedTransitions lists the related statuses a successful transaction can move to
+// VOIDED, REFUNDED and CAPTURE are final
+var relatedTransitions = map[types.RelatedTransactionStatus][]types.RelatedTransactionStatus{
+	"": {
+		types.RelatedTransactionStatusVoided, types.RelatedTransactionStatusIncremental,
+		types.RelatedTransactionStatusRefunded, types.RelatedTransactionStatusCapture,
+		types.RelatedTransactionStatusPartRefunded,
+	},
+	types.RelatedTransactionStatusIncremental: {
+		types.RelatedTransactionStatusVoided, types.RelatedTransactionStatusCapture,
+	},
+	types.RelatedTransactionStatusPartRefunded: {
+		types.RelatedTransactionStatusRefunded,
+	},
+}
+
+// CanTransition reports whether a transaction in state from may be observed in state to
+// Observing the same state again is allowed since queries and notifications repeat. An empty from
+// status means nothing is known yet and accepts any valid state
+func CanTransition(from, to State) bool {
+	if !to.IsValid() {
+		return false
+	}
+	if from == to || from.Status == "" {
+		return true
+	}
+	if from.Status != to.Status {
+		if from.Related != "" || !containsStatus(statusTransitions[from.Status], to.Status) {
+			return false
+		}
+		// A transaction reaching S may already carry the change of a subsequent transaction
+		return to.Related == "" || containsRelated(relatedTransitions[""], to.Related)
+	}
+	return containsRelated(relatedTransitions[from.Related], to.Related)
+}
+
+// IsFinal reports whether the transaction status is final (S, F or C)
+func IsFinal(resp *response.QueryResponse) bool {
+	if resp == nil {
+		return false
+	}
+	return IsFinalStatus(resp.TransactionStatus)
+}
+
+// IsFinalStatus reports whether a transaction status no longer changes (S, F or C)
+func IsFinalStatus(status types.TransactionStatus) bool {
+	switch status {
+	case types.TransactionStatusSuccess, types.TransactionStatusFail, types.TransactionStatusClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsPending reports whether the transaction is still in progress (I or P)
+func IsPending(resp *response.QueryResponse) bool {
+	if resp == nil {
+		return false
+	}
+	return resp.TransactionStatus == types.TransactionStatusInitial ||
+		resp.TransactionStatus == types.TransactionStatusProcessing
+}
+
+// CanVoid reports whether the transaction can be voided: a successful terminal transaction other
+// than a void, in an open batch, not yet voided, refunded or captured
+func CanVoid(resp *response.QueryResponse) bool {
+	if resp == nil || resp.TransactionStatus != types.TransactionStatusSuccess || resp.TerminalSN == "" {
+		return false
+	}
+	if resp.TransactionType == types.TransactionTypeVoid || resp.TransactionBatchStatus == types.TransactionBatchStatusC {
+		return false
+	}
+	return resp.RelatedTransactionStatus == "" || resp.RelatedTransactionStatus == types.RelatedTransactionStatusIncremental
+}
+
+// CanRefund reports whether the transaction can be refunded: a successful sale, post-authorization,
+// forced authorization or checkout payment, not voided and not fully refunded
+// It does not check amounts; see nexus.CalculateRefundEligibility
+func CanRefund(resp *response.QueryResponse) bool {
+	if resp == nil || resp.TransactionStatus != types.TransactionStatusSuccess {
+		return false
+	}
+	switch resp.TransactionType {
+	case types.TransactionTypeSale, types.TransactionTypePostAuth, types.TransactionTypeForcedAuth, "":
+	default:
+		return false
+	}
+	return resp.RelatedTransactionStatus == "" || resp.RelatedTransactionStatus == types.RelatedTransactionStatusPartRefunded
+}
+
+// CanCapture reports whether the transaction is a successful authorization that can still be
+// completed with a post-authorization
+func CanCapture(resp *response.QueryResponse) bool {
+	if resp == nil || resp.TransactionStatus != types.TransactionStatusSuccess || resp.TransactionType != types.TransactionTypeAuth {
+		return false
+	}
+	return resp.RelatedTransactionStatus == "" || resp.RelatedTransactionStatus == types.RelatedTransactionStatusIncremental
+}
+
+func containsStatus(list []types.TransactionStatus, s types.TransactionStatus) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRelated(list []types.RelatedTransactionStatus, s types.RelatedTransactionStatus) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package lifecycle
+
+import (
+	"testing"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+func TestCanTransition(t *testing.T) {
+	var (
+		initial    = State{Status: types.TransactionStatusInitial}
+		processing = State{Status: types.TransactionStatusProcessing}
+		success    = State{Status: types.TransactionStatusSuccess}
+		failed     = State{Status: types.TransactionStatusFail}
+		partRefund = State{Status: types.TransactionStatusSuccess, Related: types.RelatedTransactionStatusPartRefunded}
+		refunded   = State{Status: types.TransactionStatusSuccess, Related: types.RelatedTransactionStatusRefunded}
+		voided     = State{Status: types.TransactionStatusSuccess, Related: types.RelatedTransactionStatusVoided}
+		incAuth    = State{Status: types.TransactionStatusSuccess, Related: types.RelatedTransactionStatusIncremental}
+		captured   = State{Status: types.TransactionStatusSuccess, Related: types.RelatedTransactionStatusCapture}
+	)
+	cases := []struct {
+		from, to State
+		want     bool
+	}{
+		{State{}, processing, true},
+		{initial, processing, true},
+		{processing, success, true},
+		{processing, refunded, true},
+		{success, success, true},
+		{success, partRefund, true},
+		{partRefund, partRefund, true},
+		{partRefund, refunded, true},
+		{success, voided, true},
+		{incAuth, captured, true},
+		{success, processing, false},
+		{success, failed, false},
+		{failed, success, false},
+		{refunded, partRefund, false},
+		{refunded, success, false},
+		{voided, refunded, false},
+		{captured, voided, false},
+		{processing, State{Status: types.TransactionStatusFail, Related: types.RelatedTransactionStatusVoided}, false},
+		{State{}, State{Status: "X"}, false},
+	}
+	for _, tc := range cases {
+		if got := CanTransition(tc.from, tc.to); got != tc.want {
+			t.Fatalf("CanTransition(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	sale := func(related types.RelatedTransactionStatus, batch types.TransactionBatchStatus) *response.QueryResponse {
+		return &response.QueryResponse{
+			TransactionStatus:        types.TransactionStatusSuccess,
+			TransactionType:          types.TransactionTypeSale,
+			TerminalSN:               "T1",
+			RelatedTransactionStatus: related,
+			TransactionBatchStatus:   batch,
+		}
+	}
+	auth := sale(types.RelatedTransactionStatusIncremental, types.TransactionBatchStatusU)
+	auth.TransactionType = types.TransactionTypeAuth
+	pending := &response.QueryResponse{TransactionStatus: types.TransactionStatusProcessing}
+
+	cases := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"IsFinal(pending)", IsFinal(pending), false},
+		{"IsPending(pending)", IsPending(pending), true},
+		{"IsFinal(sale)", IsFinal(sale("", "")), true},
+		{"CanVoid(open batch)", CanVoid(sale("", types.TransactionBatchStatusU)), true},
+		{"CanVoid(closed batch)", CanVoid(sale("", types.TransactionBatchStatusC)), false},
+		{"CanVoid(part refunded)", CanVoid(sale(types.RelatedTransactionStatusPartRefunded, types.TransactionBatchStatusU)), false},
+		{"CanRefund(part refunded)", CanRefund(sale(types.RelatedTransactionStatusPartRefunded, types.TransactionBatchStatusC)), true},
+		{"CanRefund(refunded)", CanRefund(sale(types.RelatedTransactionStatusRefunded, types.TransactionBatchStatusC)), false},
+		{"CanRefund(auth)", CanRefund(auth), false},
+		{"CanCapture(auth)", CanCapture(auth), true},
+		{"CanCapture(sale)", CanCapture(sale("", "")), false},
+		{"CanCapture(nil)", CanCapture(nil), false},
+	}
+	for _, tc := range cases {
+		if tc.got != tc.want {
+			t.Fatalf("%s = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/webhook"
+)
+
+// Source is where a transaction state was observed
+type Source string
+
+const (
+	// SourceQuery is a Query result
+	SourceQuery Source = "QUERY"
+
+	// SourceWebhook is a transaction or checkout notification
+	SourceWebhook Source = "WEBHOOK"
+)
+
+// String returns the source code
+func (s Source) String() string {
+	return string(s)
+}
+
+// TrackerConfig holds the configuration for creating a Tracker
+type TrackerConfig struct {
+	// OnIllegal is called for each rejected state change (optional)
+	// It runs synchronously and should not block, e.g. to raise an alert
+	OnIllegal func(err *errors.TransitionError)
+}
+
+// Tracker remembers the last accepted state of each transaction and rejects observed states the
+// lifecycle does not allow, so a stale or out-of-order update never regresses a settled payment
+// Transactions are keyed by TransactionID, or TransactionRequestID when the ID is not known yet.
+// Entries are kept until Forget is called. It is safe for concurrent use
+type Tracker struct {
+	mu        sync.Mutex
+	states    map[string]State
+	onIllegal func(err *errors.TransitionError)
+}
+
+// NewTracker creates a Tracker with the given configuration (nil uses defaults)
+func NewTracker(config *TrackerConfig) *Tracker {
+	if config == nil {
+		config = &TrackerConfig{}
+	}
+	return &Tracker{
+		states:    make(map[string]State),
+		onIllegal: config.OnIllegal,
+	}
+}
+
+// Set records the state of a transaction without validation, e.g. to seed the tracker from a ledger
+func (t *Tracker) Set(transactionID string, state State) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.states[transactionID] = state
+}
+
+// State returns the last accepted state of a transaction
+func (t *Tracker) State(transactionID string) (State, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.states[transactionID]
+	return state, ok
+}
+
+// Forget drops a transaction, e.g. once it can no longer change
+func (t *Tracker) Forget(transactionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.states, transactionID)
+}
+
+// Observe validates the state reported by resp against the last accepted state
+// A legal state is recorded and returned. An illegal one is reported to OnIllegal and returned as an
+// *errors.TransitionError together with the last accepted state, which is kept
+func (t *Tracker) Observe(source Source, resp *response.QueryResponse) (State, error) {
+	if resp == nil {
+		return State{}, nil
+	}
+	key := resp.TransactionID
+	if key == "" {
+		key = resp.TransactionRequestID
+	}
+	to := StateOf(resp)
+
+	t.mu.Lock()
+	from, ok := t.states[key]
+	if !ok && resp.TransactionID != "" && resp.TransactionRequestID != "" {
+		// The transaction may have been tracked by request ID before its ID was known
+		if from, ok = t.states[resp.TransactionRequestID]; ok {
+			delete(t.states, resp.TransactionRequestID)
+		}
+	}
+	if CanTransition(from, to) {
+		t.states[key] = to
+		t.mu.Unlock()
+		return to, nil
+	}
+	if ok {
+		t.states[key] = from
+	}
+	t.mu.Unlock()
+
+	err := errors.NewTransitionError(key, source.String(), from.String(), to.String())
+	if t.onIllegal != nil {
+		t.onIllegal(err)
+	}
+	return from, err
+}
+
+// ObserveQuery validates a Query result, see Observe
+func (t *Tracker) ObserveQuery(resp *response.QueryResponse) (State, error) {
+	return t.Observe(SourceQuery, resp)
+}
+
+// ObserveEvent validates a transaction or checkout notification, see Observe
+func (t *Tracker) ObserveEvent(event *webhook.TransactionEvent) (State, error) {
+	if event == nil {
+		return State{}, nil
+	}
+	return t.Observe(SourceWebhook, &event.QueryResponse)
+}
+
+// Handler wraps a notification handler so that notifications carrying an illegal state change are
+// acknowledged without reaching next; redelivering them would not make them legal
+func (t *Tracker) Handler(next webhook.TransactionHandler) webhook.TransactionHandler {
+	return func(ctx context.Context, event *webhook.TransactionEvent) error {
+		if _, err := t.ObserveEvent(event); err != nil {
+			return nil
+		}
+		return next(ctx, event)
+	}
+}
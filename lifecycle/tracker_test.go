@@ -0,0 +1,68 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/webhook"
+)
+
+func TestTrackerRejectsRegression(t *testing.T) {
+	var reported []*errors.TransitionError
+	tracker := NewTracker(&TrackerConfig{
+		OnIllegal: func(err *errors.TransitionError) { reported = append(reported, err) },
+	})
+
+	observe := func(status types.TransactionStatus, related types.RelatedTransactionStatus) error {
+		_, err := tracker.ObserveQuery(&response.QueryResponse{
+			TransactionID:            "TX_1",
+			TransactionStatus:        status,
+			RelatedTransactionStatus: related,
+		})
+		return err
+	}
+
+	if err := observe(types.TransactionStatusProcessing, ""); err != nil {
+		t.Fatalf("Observe(P) returned error: %v", err)
+	}
+	if err := observe(types.TransactionStatusSuccess, types.RelatedTransactionStatusRefunded); err != nil {
+		t.Fatalf("Observe(S/REFUNDED) returned error: %v", err)
+	}
+	err := observe(types.TransactionStatusProcessing, "")
+	if _, ok := err.(*errors.TransitionError); !ok {
+		t.Fatalf("Observe(P) after S error = %v, want TransitionError", err)
+	}
+	if state, _ := tracker.State("TX_1"); state.String() != "S/REFUNDED" {
+		t.Fatalf("State() = %s, want S/REFUNDED", state)
+	}
+	if len(reported) != 1 || reported[0].From() != "S/REFUNDED" || reported[0].To() != "P" || reported[0].Source() != "QUERY" {
+		t.Fatalf("OnIllegal got %v, want one report from S/REFUNDED to P", reported)
+	}
+}
+
+func TestTrackerHandlerDropsIllegalEvents(t *testing.T) {
+	tracker := NewTracker(nil)
+	tracker.Set("TX_1", State{Status: types.TransactionStatusSuccess})
+
+	calls := 0
+	handler := tracker.Handler(func(ctx context.Context, event *webhook.TransactionEvent) error {
+		calls++
+		return nil
+	})
+
+	event := &webhook.TransactionEvent{}
+	event.TransactionID = "TX_1"
+	event.TransactionStatus = types.TransactionStatusFail
+	if err := handler(context.Background(), event); err != nil || calls != 0 {
+		t.Fatalf("handler(F after S) = %v with %d calls, want nil with 0 calls", err, calls)
+	}
+
+	event.TransactionStatus = types.TransactionStatusSuccess
+	event.RelatedTransactionStatus = types.RelatedTransactionStatusVoided
+	if err := handler(context.Background(), event); err != nil || calls != 1 {
+		t.Fatalf("handler(S/VOIDED) = %v with %d calls, want nil with 1 call", err, calls)
+	}
+}
@@ -5,6 +5,9 @@ type BaseResponse struct {
 	Code    string `json:"code"`
 	Msg     string `json:"msg"`
 	TraceID string `json:"traceId,omitempty"`
+
+	// Meta is the HTTP metadata of the call that produced this response
+	Meta *ResponseMeta `json:"-"`
 }
 
 // SetCode sets the response code
@@ -22,3 +25,8 @@ func (r *BaseResponse) SetTraceID(traceID string) {
 	r.TraceID = traceID
 }
 
+// SetMeta sets the HTTP metadata
+func (r *BaseResponse) SetMeta(meta *ResponseMeta) {
+	r.Meta = meta
+}
+
@@ -0,0 +1,24 @@
+package common
+
+import (
+	"net/http"
+	"time"
+)
+
+// ResponseMeta holds HTTP metadata of an API call, useful to correlate client logs with Sunbay traces
+type ResponseMeta struct {
+	// RequestID is the X-Client-Request-Id header sent with the request (identical for all attempts)
+	RequestID string
+
+	// StatusCode is the HTTP status code of the last attempt, 0 if no response was received
+	StatusCode int
+
+	// Header is the HTTP response header of the last attempt, nil if no response was received
+	Header http.Header
+
+	// Attempts is the number of attempts made
+	Attempts int
+
+	// Latency is the total duration of the call including retries
+	Latency time.Duration
+}
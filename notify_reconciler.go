@@ -0,0 +1,364 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/lifecycle"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/webhook"
+)
+
+const (
+	defaultNotifyWindow       = 5 * time.Minute
+	defaultNotifyPollInterval = time.Minute
+	defaultNotifyMaxAge       = 24 * time.Hour
+)
+
+// notifiedOperations are the operations whose notifications are tracked, mapped to their event type
+var notifiedOperations = map[string]webhook.EventType{
+	constant.OperationSale:                  webhook.EventTypeTransaction,
+	constant.OperationAuth:                  webhook.EventTypeTransaction,
+	constant.OperationForcedAuth:            webhook.EventTypeTransaction,
+	constant.OperationIncrementalAuth:       webhook.EventTypeTransaction,
+	constant.OperationPostAuth:              webhook.EventTypeTransaction,
+	constant.OperationRefund:                webhook.EventTypeTransaction,
+	constant.OperationVoid:                  webhook.EventTypeTransaction,
+	constant.OperationCreateCheckoutSession: webhook.EventTypeCheckout,
+	constant.OperationDirectPayment:         webhook.EventTypeCheckout,
+	constant.OperationOnlineRefund:          webhook.EventTypeCheckout,
+}
+
+// PendingTransaction is a transaction started with a NotifyURL whose final notification has not arrived yet
+type PendingTransaction struct {
+	// AppID is the application ID
+	AppID string `json:"appId"`
+
+	// MerchantID is the merchant ID
+	MerchantID string `json:"merchantId"`
+
+	// TransactionRequestID is the transaction request ID used to query the transaction
+	TransactionRequestID string `json:"transactionRequestId"`
+
+	// Operation is the operation that started the transaction, see constant.Operation*
+	Operation string `json:"operation"`
+
+	// StartedAt is when the transaction was started
+	StartedAt time.Time `json:"startedAt"`
+
+	// CallOptions are applied to the Query of the transaction, e.g. WithAPIKey for another merchant (optional)
+	// Tracked calls carry the API key and extra headers they were sent with. They are not serialized;
+	// set them again when restoring a transaction with Track
+	CallOptions []CallOption `json:"-"`
+
+	nextCheck time.Time
+}
+
+// NotifyReconcilerConfig holds the configuration for creating a NotifyReconciler
+type NotifyReconcilerConfig struct {
+	// Client is the client whose transactions are tracked and used to query them (required)
+	Client *NexusClient
+
+	// OnEvent receives the synthesized events, normally the same handler given to webhook.HandlerConfig (required)
+	OnEvent webhook.EventHandler
+
+	// DedupStore is shared with webhook.HandlerConfig so an event is processed once whether it arrives
+	// as a notification or is synthesized (optional)
+	DedupStore webhook.DedupStore
+
+	// Window is how long to wait for a notification before querying the transaction (optional, defaults to 5m)
+	Window time.Duration
+
+	// PollInterval is how often pending transactions are checked (optional, defaults to 1m)
+	PollInterval time.Duration
+
+	// MaxAge is how long a transaction is tracked before it is given up (optional, defaults to 24h)
+	MaxAge time.Duration
+
+	// Logger is a custom logger implementation (optional, defaults to console logger)
+	Logger Logger
+}
+
+// NotifyReconciler resolves transactions whose notification never arrived
+// It tracks transactions started through the client with a NotifyURL. When no final notification is
+// observed within the window, it queries the transaction by TransactionRequestID and delivers the same
+// typed event the webhook handler would have delivered. It is safe for concurrent use
+type NotifyReconciler struct {
+	client       *NexusClient
+	onEvent      webhook.EventHandler
+	dedup        webhook.DedupStore
+	window       time.Duration
+	pollInterval time.Duration
+	maxAge       time.Duration
+	logger       Logger
+	now          func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*PendingTransaction
+}
+
+// NewNotifyReconciler creates a NotifyReconciler and installs its tracking middleware on the client
+// Like NexusClient.Use, it must be called before the client is shared between goroutines
+func NewNotifyReconciler(config *NotifyReconcilerConfig) (*NotifyReconciler, error) {
+	if config == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"NotifyReconcilerConfig cannot be nil",
+			"",
+		)
+	}
+	if config.Client == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"Client cannot be nil",
+			"",
+		)
+	}
+	if config.OnEvent == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"OnEvent cannot be nil",
+			"",
+		)
+	}
+
+	window := config.Window
+	if window == 0 {
+		window = defaultNotifyWindow
+	}
+	pollInterval := config.PollInterval
+	if pollInterval == 0 {
+		pollInterval = defaultNotifyPollInterval
+	}
+	maxAge := config.MaxAge
+	if maxAge == 0 {
+		maxAge = defaultNotifyMaxAge
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = http.DefaultLogger()
+	}
+
+	r := &NotifyReconciler{
+		client:       config.Client,
+		onEvent:      config.OnEvent,
+		dedup:        config.DedupStore,
+		window:       window,
+		pollInterval: pollInterval,
+		maxAge:       maxAge,
+		logger:       logger,
+		now:          time.Now,
+		pending:      make(map[string]*PendingTransaction),
+	}
+	config.Client.Use(r.track)
+	return r, nil
+}
+
+// Track starts tracking a transaction, e.g. one restored from storage after a restart
+// A zero StartedAt means now
+func (r *NotifyReconciler) Track(tx PendingTransaction) {
+	if tx.TransactionRequestID == "" {
+		return
+	}
+	if tx.StartedAt.IsZero() {
+		tx.StartedAt = r.now()
+	}
+	tx.nextCheck = tx.StartedAt.Add(r.window)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[tx.TransactionRequestID] = &tx
+}
+
+// Pending returns the transactions still waiting for a final notification
+func (r *NotifyReconciler) Pending() []PendingTransaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := make([]PendingTransaction, 0, len(r.pending))
+	for _, tx := range r.pending {
+		pending = append(pending, *tx)
+	}
+	return pending
+}
+
+// Observe wraps the handler given to webhook.HandlerConfig so that transactions are untracked
+// once their final notification was processed
+func (r *NotifyReconciler) Observe(next webhook.EventHandler) webhook.EventHandler {
+	return webhook.EventHandlerFunc(func(ctx context.Context, event *webhook.Event) error {
+		if err := next.HandleEvent(ctx, event); err != nil {
+			return err
+		}
+		if tx := event.Transaction; tx != nil && lifecycle.IsFinal(&tx.QueryResponse) {
+			r.untrack(tx.TransactionRequestID)
+		}
+		return nil
+	})
+}
+
+// Run checks pending transactions every PollInterval until ctx is done
+func (r *NotifyReconciler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			r.ReconcileOnce(ctx)
+		}
+	}
+}
+
+// ReconcileOnce queries every pending transaction whose window elapsed and delivers an event for
+// those that reached a final status. It returns the number of events delivered
+func (r *NotifyReconciler) ReconcileOnce(ctx context.Context) int {
+	now := r.now()
+	var due []PendingTransaction
+	r.mu.Lock()
+	for id, tx := range r.pending {
+		if now.Sub(tx.StartedAt) > r.maxAge {
+			r.logger.Warnf("Giving up on transaction without notification - TransactionRequestID: %s, Operation: %s", id, tx.Operation)
+			delete(r.pending, id)
+			continue
+		}
+		if !now.Before(tx.nextCheck) {
+			tx.nextCheck = now.Add(r.pollInterval)
+			due = append(due, *tx)
+		}
+	}
+	r.mu.Unlock()
+
+	delivered := 0
+	for _, tx := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if r.reconcile(ctx, tx) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// reconcile queries one transaction and delivers its event if it is final
+func (r *NotifyReconciler) reconcile(ctx context.Context, tx PendingTransaction) bool {
+	q, err := r.client.Query(ctx, &request.QueryRequest{
+		AppID:                tx.AppID,
+		MerchantID:           tx.MerchantID,
+		TransactionRequestID: tx.TransactionRequestID,
+	}, tx.CallOptions...)
+	if err != nil {
+		r.logger.Warnf("Pending transaction query failed - TransactionRequestID: %s: %v", tx.TransactionRequestID, err)
+		return false
+	}
+	if !lifecycle.IsFinal(q) {
+		return false
+	}
+
+	event := newTransactionEvent(tx, q)
+	key := ""
+	if r.dedup != nil {
+		key = webhook.DedupKey(event)
+	}
+	if key != "" {
+		status, err := r.dedup.Begin(ctx, key)
+		if err != nil {
+			r.logger.Warnf("Pending transaction dedup check failed - Key: %s: %v", key, err)
+			return false
+		}
+		if status == webhook.DedupDone {
+			r.untrack(tx.TransactionRequestID)
+			return false
+		}
+		if status == webhook.DedupInProgress {
+			return false
+		}
+	}
+
+	r.logger.Infof("Delivering missed notification - TransactionRequestID: %s, Status: %s", tx.TransactionRequestID, q.TransactionStatus)
+	if err := r.onEvent.HandleEvent(ctx, event); err != nil {
+		r.logger.Errorf("Missed notification processing failed - TransactionRequestID: %s: %v", tx.TransactionRequestID, err)
+		if key != "" {
+			_ = r.dedup.Release(context.Background(), key)
+		}
+		return false
+	}
+	if key != "" {
+		if err := r.dedup.Complete(context.Background(), key); err != nil {
+			r.logger.Errorf("Missed notification dedup completion failed - Key: %s: %v", key, err)
+		}
+	}
+	r.untrack(tx.TransactionRequestID)
+	return true
+}
+
+// track is the middleware recording transactions started with a NotifyURL
+// Calls failing with a network error are tracked too, since the transaction may exist
+func (r *NotifyReconciler) track(next http.Handler) http.Handler {
+	return func(ctx context.Context, call *http.Call) error {
+		err := next(ctx, call)
+		if _, ok := notifiedOperations[call.Operation]; !ok || requestField(call.Request, "NotifyURL") == "" {
+			return err
+		}
+		if _, ok := err.(*errors.NetworkError); err == nil || ok {
+			r.Track(PendingTransaction{
+				AppID:                requestField(call.Request, "AppID"),
+				MerchantID:           requestField(call.Request, "MerchantID"),
+				TransactionRequestID: requestField(call.Request, "TransactionRequestID"),
+				Operation:            call.Operation,
+				CallOptions:          queryCallOptions(call.Options),
+			})
+		}
+		return err
+	}
+}
+
+func (r *NotifyReconciler) untrack(transactionRequestID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, transactionRequestID)
+}
+
+// newTransactionEvent synthesizes the notification event of a queried transaction
+func newTransactionEvent(tx PendingTransaction, q *response.QueryResponse) *webhook.Event {
+	payload := &webhook.TransactionEvent{
+		QueryResponse: *q,
+		AppID:         tx.AppID,
+		MerchantID:    tx.MerchantID,
+	}
+	payload.Meta = nil
+	raw, _ := json.Marshal(payload)
+
+	eventType := notifiedOperations[tx.Operation]
+	if eventType == "" {
+		eventType = webhook.EventTypeTransaction
+	}
+	return &webhook.Event{
+		Type:        eventType,
+		Transaction: payload,
+		Raw:         raw,
+	}
+}
+
+// queryCallOptions returns the call options that let Query use the credentials and extra headers of a call
+func queryCallOptions(opts *http.RequestOptions) []CallOption {
+	if opts == nil {
+		return nil
+	}
+	var callOpts []CallOption
+	if opts.APIKey != "" {
+		callOpts = append(callOpts, WithAPIKey(opts.APIKey))
+	}
+	for name, values := range opts.Header {
+		for _, value := range values {
+			callOpts = append(callOpts, WithHeader(name, value))
+		}
+	}
+	return callOpts
+}
@@ -0,0 +1,103 @@
+package nexus
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/webhook"
+)
+
+func TestNotifyReconcilerDeliversMissedNotification(t *testing.T) {
+	status := "P"
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathSale:
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionRequestId":"REQ_1","transactionStatus":"P"}}`))
+		case constant.PathQuery:
+			if got := r.Header.Get("Authorization"); got != "Bearer merchant-key" {
+				t.Errorf("query Authorization = %q, want the API key of the Sale", got)
+			}
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionRequestId":"REQ_1","transactionType":"SALE","transactionStatus":"` + status + `"}}`))
+		}
+	}, Config{})
+
+	var events []*webhook.Event
+	store := webhook.NewMemoryDedupStore(nil)
+	reconciler, err := NewNotifyReconciler(&NotifyReconcilerConfig{
+		Client: client,
+		OnEvent: webhook.EventHandlerFunc(func(ctx context.Context, event *webhook.Event) error {
+			events = append(events, event)
+			return nil
+		}),
+		DedupStore:   store,
+		Window:       time.Minute,
+		PollInterval: time.Minute,
+		Logger:       nopLogger{},
+	})
+	if err != nil {
+		t.Fatalf("NewNotifyReconciler() returned error: %v", err)
+	}
+	now := time.Now()
+	reconciler.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	if _, err := client.Sale(ctx, &request.SaleRequest{AppID: "app", MerchantID: "mch", TransactionRequestID: "REQ_0"}); err != nil {
+		t.Fatalf("Sale() returned error: %v", err)
+	}
+	if _, err := client.Sale(ctx, &request.SaleRequest{AppID: "app", MerchantID: "mch", TransactionRequestID: "REQ_1", NotifyURL: "https://example.com/notify"},
+		WithAPIKey("merchant-key")); err != nil {
+		t.Fatalf("Sale() returned error: %v", err)
+	}
+	if pending := reconciler.Pending(); len(pending) != 1 || pending[0].TransactionRequestID != "REQ_1" {
+		t.Fatalf("Pending() = %+v, want only REQ_1", pending)
+	}
+
+	if n := reconciler.ReconcileOnce(ctx); n != 0 {
+		t.Fatalf("ReconcileOnce() within window delivered %d events, want 0", n)
+	}
+	now = now.Add(2 * time.Minute)
+	if n := reconciler.ReconcileOnce(ctx); n != 0 {
+		t.Fatalf("ReconcileOnce() for pending transaction delivered %d events, want 0", n)
+	}
+
+	status = "S"
+	now = now.Add(2 * time.Minute)
+	if n := reconciler.ReconcileOnce(ctx); n != 1 {
+		t.Fatalf("ReconcileOnce() delivered %d events, want 1", n)
+	}
+	event := events[0]
+	if event.Type != webhook.EventTypeTransaction || event.Transaction.TransactionStatus != types.TransactionStatusSuccess ||
+		event.Transaction.MerchantID != "mch" || len(event.Raw) == 0 {
+		t.Fatalf("unexpected synthesized event: %+v", event)
+	}
+	if len(reconciler.Pending()) != 0 {
+		t.Fatal("transaction still pending after delivery")
+	}
+	if got, _ := store.Begin(ctx, webhook.DedupKey(event)); got != webhook.DedupDone {
+		t.Fatalf("dedup status = %s, want %s", got, webhook.DedupDone)
+	}
+}
+
+func TestNotifyReconcilerObserveUntracks(t *testing.T) {
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {}, Config{})
+	reconciler, _ := NewNotifyReconciler(&NotifyReconcilerConfig{
+		Client:  client,
+		OnEvent: webhook.EventHandlerFunc(func(ctx context.Context, event *webhook.Event) error { return nil }),
+		Logger:  nopLogger{},
+	})
+	reconciler.Track(PendingTransaction{TransactionRequestID: "REQ_1", Operation: constant.OperationSale})
+
+	handler := reconciler.Observe(webhook.EventHandlerFunc(func(ctx context.Context, event *webhook.Event) error { return nil }))
+	event, _ := webhook.ParseEvent([]byte(`{"transactionId":"TX_1","transactionRequestId":"REQ_1","transactionStatus":"S"}`))
+	if err := handler.HandleEvent(context.Background(), event); err != nil {
+		t.Fatalf("HandleEvent() returned error: %v", err)
+	}
+	if len(reconciler.Pending()) != 0 {
+		t.Fatal("transaction still pending after final notification")
+	}
+}
@@ -0,0 +1,72 @@
+package nexus
+
+import (
+	nethttp "net/http"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+)
+
+// CallOption customizes a single API call
+type CallOption func(*http.RequestOptions)
+
+// WithTimeout overrides the timeout of each attempt of this call,
+// taking precedence over Config.OperationTimeouts and Config.ReadTimeout
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(o *http.RequestOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// WithClientRequestID sets the X-Client-Request-Id header of this call instead of a generated one
+func WithClientRequestID(requestID string) CallOption {
+	return func(o *http.RequestOptions) {
+		o.ClientRequestID = requestID
+	}
+}
+
+// WithHeader adds an extra header to this call
+// Calling it several times with the same name adds several values
+func WithHeader(name, value string) CallOption {
+	return func(o *http.RequestOptions) {
+		if o.Header == nil {
+			o.Header = make(nethttp.Header)
+		}
+		o.Header.Add(name, value)
+	}
+}
+
+// WithMaxRetries caps the number of retries after the initial attempt for this call (0 disables retries)
+func WithMaxRetries(maxRetries int) CallOption {
+	return func(o *http.RequestOptions) {
+		if maxRetries < 0 {
+			maxRetries = 0
+		}
+		o.MaxRetries = &maxRetries
+	}
+}
+
+// WithIdempotentRetry overrides Config.IdempotentRetry for this call
+func WithIdempotentRetry(enabled bool) CallOption {
+	return func(o *http.RequestOptions) {
+		o.RetryPost = &enabled
+	}
+}
+
+// WithAPIKey uses a different API key for this call, e.g. on multi-merchant platforms
+func WithAPIKey(apiKey string) CallOption {
+	return func(o *http.RequestOptions) {
+		o.APIKey = apiKey
+	}
+}
+
+// requestOptions builds the HTTP request options for an operation from the call options
+func requestOptions(operation string, opts []CallOption) *http.RequestOptions {
+	options := &http.RequestOptions{Operation: operation}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(options)
+		}
+	}
+	return options
+}
@@ -0,0 +1,41 @@
+package nexus
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/ratelimit"
+)
+
+// rateLimitGate waits for the limiter before every attempt, retries included, scoped by the MerchantID
+// and TerminalSN of the request model
+func rateLimitGate(limiter *ratelimit.Limiter) http.AttemptGate {
+	return func(ctx context.Context, call *http.Call) error {
+		merchantID, terminalSN := requestScope(call.Request)
+		return limiter.Wait(ctx, merchantID, terminalSN)
+	}
+}
+
+// requestScope extracts the MerchantID and TerminalSN fields of a request model
+func requestScope(req interface{}) (merchantID, terminalSN string) {
+	return requestField(req, "MerchantID"), requestField(req, "TerminalSN")
+}
+
+// requestField returns the named string field of a request model, or "" if it has none
+func requestField(req interface{}, name string) string {
+	v := reflect.ValueOf(req)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.FieldByName(name); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
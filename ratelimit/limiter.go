@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Config holds the rate limiter configuration
+// Each scope is optional; a nil Limit disables that scope
+type Config struct {
+	// Global limits all requests of the client
+	Global *Limit
+
+	// PerMerchant limits requests per MerchantID
+	PerMerchant *Limit
+
+	// PerTerminal limits requests per TerminalSN
+	PerTerminal *Limit
+
+	// OnWait is called after a request was delayed by the limiter (optional)
+	// It can be used to export wait time metrics
+	OnWait func(merchantID, terminalSN string, wait time.Duration)
+}
+
+// Metrics is a snapshot of the limiter statistics
+type Metrics struct {
+	// Requests is the number of requests that asked for permission
+	Requests int64
+
+	// Delayed is the number of requests that had to wait
+	Delayed int64
+
+	// Rejected is the number of requests whose context ended before they were allowed
+	Rejected int64
+
+	// TotalWait is the total time requests spent waiting
+	TotalWait time.Duration
+
+	// MaxWait is the longest time a single request spent waiting
+	MaxWait time.Duration
+}
+
+// Limiter is a client-side rate limiter with global, per-merchant and per-terminal scopes
+// A request waits until every applicable scope has a token. The limiter is safe for concurrent use
+type Limiter struct {
+	config    Config
+	global    *TokenBucket
+	mu        sync.Mutex
+	merchants map[string]*TokenBucket
+	terminals map[string]*TokenBucket
+	metrics   Metrics
+	now       func() time.Time
+}
+
+// NewLimiter creates a rate limiter
+func NewLimiter(config Config) *Limiter {
+	l := &Limiter{
+		config:    config,
+		merchants: make(map[string]*TokenBucket),
+		terminals: make(map[string]*TokenBucket),
+		now:       time.Now,
+	}
+	if config.Global != nil {
+		l.global = NewTokenBucket(*config.Global)
+	}
+	return l
+}
+
+// Wait blocks until the request is allowed or ctx is done
+// An empty merchantID or terminalSN skips the corresponding scope.
+// If ctx has a deadline that will pass before the request is allowed, Wait returns
+// context.DeadlineExceeded immediately without consuming tokens
+func (l *Limiter) Wait(ctx context.Context, merchantID, terminalSN string) error {
+	buckets := l.buckets(merchantID, terminalSN)
+	now := l.now()
+
+	var wait time.Duration
+	for _, b := range buckets {
+		if d := b.reserve(now); d > wait {
+			wait = d
+		}
+	}
+
+	if wait == 0 {
+		l.record(0, false)
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		l.release(buckets)
+		l.record(0, true)
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.release(buckets)
+		l.record(l.now().Sub(now), true)
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	l.record(wait, false)
+	if l.config.OnWait != nil {
+		l.config.OnWait(merchantID, terminalSN, wait)
+	}
+	return nil
+}
+
+// Metrics returns a snapshot of the limiter statistics
+func (l *Limiter) Metrics() Metrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.metrics
+}
+
+// buckets returns the token buckets that apply to a request
+func (l *Limiter) buckets(merchantID, terminalSN string) []*TokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var buckets []*TokenBucket
+	if l.global != nil {
+		buckets = append(buckets, l.global)
+	}
+	if l.config.PerMerchant != nil && merchantID != "" {
+		buckets = append(buckets, bucketFor(l.merchants, merchantID, *l.config.PerMerchant))
+	}
+	if l.config.PerTerminal != nil && terminalSN != "" {
+		buckets = append(buckets, bucketFor(l.terminals, terminalSN, *l.config.PerTerminal))
+	}
+	return buckets
+}
+
+// bucketFor returns the bucket for key, creating it on first use
+func bucketFor(buckets map[string]*TokenBucket, key string, limit Limit) *TokenBucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = NewTokenBucket(limit)
+		buckets[key] = b
+	}
+	return b
+}
+
+// release returns the tokens reserved for a request that will not be sent
+func (l *Limiter) release(buckets []*TokenBucket) {
+	now := l.now()
+	for _, b := range buckets {
+		b.cancel(now)
+	}
+}
+
+// record updates the limiter statistics
+func (l *Limiter) record(wait time.Duration, rejected bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.metrics.Requests++
+	if rejected {
+		l.metrics.Rejected++
+	} else if wait > 0 {
+		l.metrics.Delayed++
+	}
+	l.metrics.TotalWait += wait
+	if wait > l.metrics.MaxWait {
+		l.metrics.MaxWait = wait
+	}
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterScopes(t *testing.T) {
+	limiter := NewLimiter(Config{
+		PerTerminal: &Limit{Rate: 10, Burst: 1},
+	})
+	ctx := context.Background()
+
+	if err := limiter.Wait(ctx, "mch", "T1"); err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+	// A different terminal has its own bucket
+	if err := limiter.Wait(ctx, "mch", "T2"); err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+
+	start := time.Now()
+	if err := limiter.Wait(ctx, "mch", "T1"); err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("second request on T1 waited %v, want about 100ms", elapsed)
+	}
+
+	metrics := limiter.Metrics()
+	if metrics.Requests != 3 || metrics.Delayed != 1 || metrics.MaxWait <= 0 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+}
+
+func TestLimiterRejectsWhenDeadlineTooClose(t *testing.T) {
+	limiter := NewLimiter(Config{Global: &Limit{Rate: 1, Burst: 1}})
+	if err := limiter.Wait(context.Background(), "", ""); err != nil {
+		t.Fatalf("Wait() returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := limiter.Wait(ctx, "", ""); err != context.DeadlineExceeded {
+		t.Fatalf("Wait() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Fatalf("Wait() blocked for %v, want immediate rejection", elapsed)
+	}
+	if metrics := limiter.Metrics(); metrics.Rejected != 1 {
+		t.Fatalf("Rejected = %d, want 1", metrics.Rejected)
+	}
+}
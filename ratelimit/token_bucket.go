@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limit describes a token bucket
+type Limit struct {
+	// Rate is the number of requests allowed per second
+	Rate float64
+
+	// Burst is the maximum number of requests allowed at once (optional, defaults to 1)
+	Burst int
+}
+
+// TokenBucket is a token bucket rate limiter that is safe for concurrent use
+// Tokens are reserved ahead of time, so waiting callers are served in FIFO order
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a full token bucket
+func NewTokenBucket(limit Limit) *TokenBucket {
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   limit.Rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// reserve takes one token and returns how long the caller must wait before using it
+func (b *TokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate <= 0 {
+		return 0
+	}
+	b.advance(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token that will not be used
+func (b *TokenBucket) cancel(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate <= 0 {
+		return
+	}
+	b.advance(now)
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// advance refills the bucket for the time elapsed since the last update
+func (b *TokenBucket) advance(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+}
@@ -0,0 +1,157 @@
+// Package reconcile compares locally recorded transactions with what SUNBAY Nexus reports
+//
+// Each local record is looked up by Query and compared by amount and status; batch totals from
+// BatchQuery and BatchClose are compared per terminal with the sums of the local records in the batch.
+// The differences are returned as a structured Report
+package reconcile
+
+import (
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+// Record is a transaction as recorded locally
+type Record struct {
+	// TransactionID is the SUNBAY Nexus transaction ID; either it or TransactionRequestID is required
+	TransactionID string
+
+	// TransactionRequestID is the request ID of the transaction
+	TransactionRequestID string
+
+	// AppID is the application ID
+	AppID string
+
+	// MerchantID is the merchant ID
+	MerchantID string
+
+	// TerminalSN is the terminal serial number, empty for checkout payments
+	TerminalSN string
+
+	// Type is the transaction type; refunds count negatively in batch totals
+	// (optional, defaults to the type Query reports)
+	Type types.TransactionType
+
+	// Status is the recorded status (optional, not compared when empty)
+	Status types.TransactionStatus
+
+	// Currency is the transaction currency (ISO 4217)
+	Currency string
+
+	// Amount is the transaction total in cents
+	Amount int64
+}
+
+// DiffKind is the kind of a difference
+type DiffKind string
+
+const (
+	// DiffMissingLocally is a transaction known to Nexus without a local record
+	DiffMissingLocally DiffKind = "MISSING_LOCALLY"
+
+	// DiffMissingRemotely is a local record that Query reports not found
+	DiffMissingRemotely DiffKind = "MISSING_REMOTELY"
+
+	// DiffAmountMismatch is a transaction whose amount or currency differs
+	DiffAmountMismatch DiffKind = "AMOUNT_MISMATCH"
+
+	// DiffStatusMismatch is a transaction whose status differs
+	DiffStatusMismatch DiffKind = "STATUS_MISMATCH"
+
+	// DiffBatchTotalMismatch is a batch whose transaction count or net amount differs from the local records
+	DiffBatchTotalMismatch DiffKind = "BATCH_TOTAL_MISMATCH"
+
+	// DiffBatchMissingRemotely is a batch of local records that neither BatchQuery nor the BatchClose
+	// results of its terminal report
+	DiffBatchMissingRemotely DiffKind = "BATCH_MISSING_REMOTELY"
+)
+
+// String returns the kind code
+func (k DiffKind) String() string {
+	return string(k)
+}
+
+// Diff is one difference between the local records and Nexus
+type Diff struct {
+	// Kind is the kind of difference
+	Kind DiffKind
+
+	// TransactionID is the transaction ID, empty for batch differences
+	TransactionID string
+
+	// TransactionRequestID is the transaction request ID, empty for batch differences
+	TransactionRequestID string
+
+	// TerminalSN is the terminal of the transaction or batch
+	TerminalSN string
+
+	// BatchNo is the batch of the transaction, when known
+	BatchNo string
+
+	// Currency is the local currency, or the remote one when there is no local record
+	Currency string
+
+	// Local is the local record, nil for DiffMissingLocally and batch differences
+	Local *Record
+
+	// Remote is the query result, nil for DiffMissingRemotely and batch differences
+	Remote *response.QueryResponse
+
+	// LocalAmount and RemoteAmount are the compared amounts in cents
+	LocalAmount  int64
+	RemoteAmount int64
+
+	// LocalStatus and RemoteStatus are the compared statuses
+	LocalStatus  types.TransactionStatus
+	RemoteStatus types.TransactionStatus
+
+	// LocalCount and RemoteCount are the compared transaction counts of a batch
+	LocalCount  int
+	RemoteCount int
+}
+
+// LookupError is a Query or BatchQuery call that failed
+type LookupError struct {
+	// Record is the local record whose Query failed, nil for BatchQuery
+	Record *Record
+
+	// TerminalSN is the terminal whose BatchQuery failed, empty for Query
+	TerminalSN string
+
+	// Err is the error
+	Err error
+}
+
+// Report is the result of a reconciliation
+type Report struct {
+	// Checked is the number of local records looked up
+	Checked int
+
+	// Matched is the number of local records found with the same amount and status
+	Matched int
+
+	// Batches is the number of batches compared
+	Batches int
+
+	// Diffs are the differences found: records in input order, then transactions missing locally, then batches
+	Diffs []Diff
+
+	// Errors are the lookups that failed; their records and the batches they may affect (all batches
+	// of a failed BatchQuery, those of the terminal and currency of a failed Query) are not compared
+	Errors []LookupError
+}
+
+// OK reports whether everything matched and every lookup succeeded
+func (r *Report) OK() bool {
+	return len(r.Diffs) == 0 && len(r.Errors) == 0
+}
+
+// Count returns the number of differences of a kind
+func (r *Report) Count(kind DiffKind) int {
+	n := 0
+	for _, d := range r.Diffs {
+		if d.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,409 @@
+package reconcile
+
+import (
+	"context"
+	"sync"
+
+	nexus "github.com/sunbay-developer/sunbay-nexus-sdk-go"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+const defaultConcurrency = 4
+
+// Config holds the configuration for creating a Reconciler
+type Config struct {
+	// Client is used for Query and BatchQuery (required)
+	Client *nexus.NexusClient
+
+	// Concurrency is how many queries run at once (optional, defaults to 4)
+	Concurrency int
+
+	// CallOptions are applied to every call (optional)
+	CallOptions []nexus.CallOption
+}
+
+// Terminal identifies a terminal whose open batch is compared through BatchQuery
+type Terminal struct {
+	// AppID is the application ID
+	AppID string
+
+	// MerchantID is the merchant ID
+	MerchantID string
+
+	// TerminalSN is the terminal serial number
+	TerminalSN string
+}
+
+// Input is what a reconciliation compares
+type Input struct {
+	// Records are the local transaction records
+	Records []Record
+
+	// Remote are transactions Nexus reported, e.g. collected notifications (optional)
+	// Those without a local record are reported as DiffMissingLocally
+	Remote []*response.QueryResponse
+
+	// Terminals are compared on their open batch totals from BatchQuery (optional)
+	Terminals []Terminal
+
+	// BatchCloses are closed batches compared on their totals (optional)
+	BatchCloses []*response.BatchCloseResponse
+}
+
+// Reconciler compares local transaction records with Nexus
+type Reconciler struct {
+	client      *nexus.NexusClient
+	concurrency int
+	callOptions []nexus.CallOption
+}
+
+// NewReconciler creates a Reconciler with the given configuration
+func NewReconciler(config *Config) (*Reconciler, error) {
+	if config == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"Config cannot be nil",
+			"",
+		)
+	}
+	if config.Client == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"Client cannot be nil",
+			"",
+		)
+	}
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	return &Reconciler{
+		client:      config.Client,
+		concurrency: concurrency,
+		callOptions: config.CallOptions,
+	}, nil
+}
+
+// batchKey identifies the totals of one currency in one batch of a terminal
+type batchKey struct {
+	terminalSN string
+	batchNo    string
+	currency   string
+}
+
+// batchTotals are the transaction count and net amount of a batch
+type batchTotals struct {
+	count int
+	net   int64
+}
+
+// Run looks up every record by Query and compares the batch totals
+// A record is looked up by TransactionID, or TransactionRequestID when the ID is empty. A record Query
+// reports not found is DiffMissingRemotely; other failed lookups are listed in Report.Errors. Local
+// batches of a compared terminal that Nexus did not report are DiffBatchMissingRemotely. Batch totals
+// count successful sales, post-authorizations and forced authorizations, minus refunds, that are not
+// voided; records are assigned to batches by the BatchNo Query reports. The batch of a record whose lookup
+// failed is unknown, so no batch of its terminal and currency is compared. Only ctx ending returns an error
+func (r *Reconciler) Run(ctx context.Context, in *Input) (*Report, error) {
+	if in == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"Input cannot be nil",
+			"",
+		)
+	}
+
+	remotes, lookupErrs := r.queryRecords(ctx, in.Records)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	report := &Report{}
+	known := make(map[string]bool)
+	remember := func(ids ...string) {
+		for _, id := range ids {
+			if id != "" {
+				known[id] = true
+			}
+		}
+	}
+	locals := make(map[batchKey]batchTotals)
+	var localOrder []batchKey
+	var failed []*Record
+	for i := range in.Records {
+		record := &in.Records[i]
+		remember(record.TransactionID, record.TransactionRequestID)
+		if lookupErrs[i] != nil {
+			report.Errors = append(report.Errors, LookupError{Record: record, Err: lookupErrs[i]})
+			failed = append(failed, record)
+			continue
+		}
+		report.Checked++
+
+		remote := remotes[i]
+		if remote == nil {
+			report.Diffs = append(report.Diffs, Diff{
+				Kind:                 DiffMissingRemotely,
+				TransactionID:        record.TransactionID,
+				TransactionRequestID: record.TransactionRequestID,
+				TerminalSN:           record.TerminalSN,
+				Currency:             record.Currency,
+				Local:                record,
+				LocalAmount:          record.Amount,
+				LocalStatus:          record.Status,
+			})
+			continue
+		}
+		remember(remote.TransactionID, remote.TransactionRequestID)
+
+		diffs := compareRecord(record, remote)
+		if len(diffs) == 0 {
+			report.Matched++
+		}
+		report.Diffs = append(report.Diffs, diffs...)
+
+		if sign := settledSign(record, remote); sign != 0 {
+			key := batchKey{terminalOf(record, remote), remote.BatchNo, record.Currency}
+			totals, ok := locals[key]
+			if !ok {
+				localOrder = append(localOrder, key)
+			}
+			totals.count++
+			totals.net += sign * record.Amount
+			locals[key] = totals
+		}
+	}
+
+	for _, remote := range in.Remote {
+		if remote == nil || known[remote.TransactionID] || known[remote.TransactionRequestID] {
+			continue
+		}
+		d := Diff{
+			Kind:                 DiffMissingLocally,
+			TransactionID:        remote.TransactionID,
+			TransactionRequestID: remote.TransactionRequestID,
+			TerminalSN:           remote.TerminalSN,
+			BatchNo:              remote.BatchNo,
+			Remote:               remote,
+			RemoteAmount:         remoteAmount(remote),
+			RemoteStatus:         remote.TransactionStatus,
+		}
+		if remote.Amount != nil {
+			d.Currency = remote.Amount.PriceCurrency
+		}
+		report.Diffs = append(report.Diffs, d)
+	}
+
+	remoteBatches := make(map[batchKey]batchTotals)
+	var batchOrder []batchKey
+	compared := make(map[string]bool)
+	addBatch := func(key batchKey, count int, net int64) {
+		totals, ok := remoteBatches[key]
+		if !ok {
+			batchOrder = append(batchOrder, key)
+		}
+		totals.count += count
+		totals.net += net
+		remoteBatches[key] = totals
+	}
+	for _, terminal := range in.Terminals {
+		resp, err := r.client.BatchQuery(ctx, &request.BatchQueryRequest{
+			AppID:      terminal.AppID,
+			MerchantID: terminal.MerchantID,
+			TerminalSN: terminal.TerminalSN,
+		}, r.callOptions...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			report.Errors = append(report.Errors, LookupError{TerminalSN: terminal.TerminalSN, Err: err})
+			continue
+		}
+		compared[terminal.TerminalSN] = true
+		// Items are split by channel; the local records are not
+		for _, item := range resp.BatchList {
+			addBatch(batchKey{terminal.TerminalSN, item.BatchNo, item.PriceCurrency}, item.TotalCount, item.NetAmount)
+		}
+	}
+	for _, closed := range in.BatchCloses {
+		if closed != nil {
+			compared[closed.TerminalSN] = true
+			addBatch(batchKey{closed.TerminalSN, closed.BatchNo, closed.PriceCurrency}, closed.TransactionCount, closed.NetAmount)
+		}
+	}
+
+	for _, key := range batchOrder {
+		if mayHoldAny(key, failed) {
+			continue
+		}
+		remote, local := remoteBatches[key], locals[key]
+		report.Batches++
+		if remote != local {
+			report.Diffs = append(report.Diffs, Diff{
+				Kind:         DiffBatchTotalMismatch,
+				TerminalSN:   key.terminalSN,
+				BatchNo:      key.batchNo,
+				Currency:     key.currency,
+				LocalAmount:  local.net,
+				RemoteAmount: remote.net,
+				LocalCount:   local.count,
+				RemoteCount:  remote.count,
+			})
+		}
+	}
+	// Batches of the compared terminals that Nexus did not report
+	for _, key := range localOrder {
+		if _, ok := remoteBatches[key]; ok || !compared[key.terminalSN] || mayHoldAny(key, failed) {
+			continue
+		}
+		local := locals[key]
+		report.Batches++
+		report.Diffs = append(report.Diffs, Diff{
+			Kind:        DiffBatchMissingRemotely,
+			TerminalSN:  key.terminalSN,
+			BatchNo:     key.batchNo,
+			Currency:    key.currency,
+			LocalAmount: local.net,
+			LocalCount:  local.count,
+		})
+	}
+	return report, nil
+}
+
+// queryRecords looks up the records concurrently
+// The result of a record is nil when Query reports it not found; other errors are returned per record
+func (r *Reconciler) queryRecords(ctx context.Context, records []Record) ([]*response.QueryResponse, []error) {
+	remotes := make([]*response.QueryResponse, len(records))
+	errs := make([]error, len(records))
+	sem := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	for i := range records {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return remotes, errs
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			record := &records[i]
+			req := &request.QueryRequest{AppID: record.AppID, MerchantID: record.MerchantID}
+			if record.TransactionID != "" {
+				req.TransactionID = record.TransactionID
+			} else {
+				req.TransactionRequestID = record.TransactionRequestID
+			}
+			resp, err := r.client.Query(ctx, req, r.callOptions...)
+			switch {
+			case err == nil:
+				remotes[i] = resp
+			case r.client.IsTransactionNotFound(err):
+				// Missing remotely
+			default:
+				errs[i] = err
+			}
+		}(i)
+	}
+	wg.Wait()
+	return remotes, errs
+}
+
+// compareRecord compares a record with its query result
+func compareRecord(record *Record, remote *response.QueryResponse) []Diff {
+	base := Diff{
+		TransactionID:        remote.TransactionID,
+		TransactionRequestID: remote.TransactionRequestID,
+		TerminalSN:           terminalOf(record, remote),
+		BatchNo:              remote.BatchNo,
+		Currency:             record.Currency,
+		Local:                record,
+		Remote:               remote,
+		LocalAmount:          record.Amount,
+		RemoteAmount:         remoteAmount(remote),
+		LocalStatus:          record.Status,
+		RemoteStatus:         remote.TransactionStatus,
+	}
+	var diffs []Diff
+	remoteCurrency := ""
+	if remote.Amount != nil {
+		remoteCurrency = remote.Amount.PriceCurrency
+	}
+	if base.LocalAmount != base.RemoteAmount || (remoteCurrency != "" && record.Currency != remoteCurrency) {
+		d := base
+		d.Kind = DiffAmountMismatch
+		diffs = append(diffs, d)
+	}
+	if record.Status != "" && record.Status != remote.TransactionStatus {
+		d := base
+		d.Kind = DiffStatusMismatch
+		diffs = append(diffs, d)
+	}
+	return diffs
+}
+
+// settledSign returns how a record counts in its batch net amount: 1, -1 for refunds, or 0 when it does
+// not count. The type is the recorded one, or the one Query reports when the record has none
+func settledSign(record *Record, q *response.QueryResponse) int64 {
+	if q.TransactionStatus != types.TransactionStatusSuccess || q.RelatedTransactionStatus == types.RelatedTransactionStatusVoided {
+		return 0
+	}
+	transactionType := record.Type
+	if transactionType == "" {
+		transactionType = q.TransactionType
+	}
+	switch transactionType {
+	case types.TransactionTypeSale, types.TransactionTypePostAuth, types.TransactionTypeForcedAuth:
+		return 1
+	case types.TransactionTypeRefund:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// mayHoldAny reports whether a batch may hold one of the records whose lookup failed
+// A record without a terminal or currency may be in a batch of any terminal or currency
+func mayHoldAny(key batchKey, records []*Record) bool {
+	for _, record := range records {
+		if (record.TerminalSN == "" || record.TerminalSN == key.terminalSN) &&
+			(record.Currency == "" || record.Currency == key.currency) {
+			return true
+		}
+	}
+	return false
+}
+
+// terminalOf returns the terminal of a record, falling back to the one Query reports
+func terminalOf(record *Record, remote *response.QueryResponse) string {
+	if record.TerminalSN != "" {
+		return record.TerminalSN
+	}
+	return remote.TerminalSN
+}
+
+// remoteAmount returns the transaction total of a query result in cents
+func remoteAmount(q *response.QueryResponse) int64 {
+	if q.Amount == nil {
+		return 0
+	}
+	if q.Amount.TransAmount != nil {
+		return *q.Amount.TransAmount
+	}
+	return sum(q.Amount.OrderAmount, q.Amount.TipAmount, q.Amount.TaxAmount, q.Amount.SurchargeAmount)
+}
+
+func sum(values ...*int64) int64 {
+	var total int64
+	for _, v := range values {
+		if v != nil {
+			total += *v
+		}
+	}
+	return total
+}
@@ -0,0 +1,129 @@
+package reconcile
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	nexus "github.com/sunbay-developer/sunbay-nexus-sdk-go"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(args ...interface{})                 {}
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+func (nopLogger) Info(args ...interface{})                  {}
+func (nopLogger) Infof(format string, args ...interface{})  {}
+func (nopLogger) Warn(args ...interface{})                  {}
+func (nopLogger) Warnf(format string, args ...interface{})  {}
+func (nopLogger) Error(args ...interface{})                 {}
+func (nopLogger) Errorf(format string, args ...interface{}) {}
+
+func TestReconcilerRun(t *testing.T) {
+	transactions := map[string]string{
+		"TX_1":  `"transactionId":"TX_1","transactionType":"SALE","transactionStatus":"S","terminalSn":"T1","batchNo":"B1","amount":{"priceCurrency":"USD","transAmount":1000}`,
+		"TX_2":  `"transactionId":"TX_2","transactionType":"SALE","transactionStatus":"S","terminalSn":"T1","batchNo":"B1","amount":{"priceCurrency":"USD","orderAmount":500,"tipAmount":100}`,
+		"REQ_3": `"transactionId":"TX_3","transactionRequestId":"REQ_3","transactionType":"SALE","transactionStatus":"F","terminalSn":"T1","amount":{"priceCurrency":"USD","transAmount":700}`,
+		"TX_5":  `"transactionId":"TX_5","transactionStatus":"S","terminalSn":"T1","batchNo":"B1","amount":{"priceCurrency":"USD","transAmount":200}`,
+		"TX_6":  `"transactionId":"TX_6","transactionType":"SALE","transactionStatus":"S","terminalSn":"T1","batchNo":"B0","amount":{"priceCurrency":"USD","transAmount":400}`,
+		"TX_7":  `"transactionId":"TX_7","transactionType":"SALE","transactionStatus":"S","terminalSn":"T2","batchNo":"B5","amount":{"priceCurrency":"USD","transAmount":900}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathQuery:
+			id := r.URL.Query().Get("transactionId")
+			if id == "" {
+				id = r.URL.Query().Get("transactionRequestId")
+			}
+			if data, ok := transactions[id]; ok {
+				_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{` + data + `}}`))
+				return
+			}
+			if id == "TX_8" {
+				_, _ = w.Write([]byte(`{"code":"A401","msg":"unauthorized"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":"T404","msg":"transaction not found"}`))
+		case constant.PathBatchQuery:
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"batchList":[` +
+				`{"batchNo":"B1","channelCode":"VISA","priceCurrency":"USD","totalCount":2,"netAmount":1100},` +
+				`{"batchNo":"B1","channelCode":"MC","priceCurrency":"USD","totalCount":1,"netAmount":300},` +
+				`{"batchNo":"B1","channelCode":"VISA","priceCurrency":"EUR","totalCount":1,"netAmount":100}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := nexus.NewNexusClient(&nexus.Config{
+		APIKey:                   "test-api-key",
+		BaseURL:                  server.URL,
+		Logger:                   nopLogger{},
+		TransactionNotFoundCodes: []string{"T404"},
+	})
+	if err != nil {
+		t.Fatalf("NewNexusClient() returned error: %v", err)
+	}
+	reconciler, err := NewReconciler(&Config{Client: client})
+	if err != nil {
+		t.Fatalf("NewReconciler() returned error: %v", err)
+	}
+
+	report, err := reconciler.Run(context.Background(), &Input{
+		Records: []Record{
+			{TransactionID: "TX_1", TerminalSN: "T1", Type: types.TransactionTypeSale, Status: types.TransactionStatusSuccess, Currency: "USD", Amount: 1000},
+			{TransactionID: "TX_2", TerminalSN: "T1", Type: types.TransactionTypeSale, Currency: "USD", Amount: 500},
+			{TransactionRequestID: "REQ_3", TerminalSN: "T1", Type: types.TransactionTypeSale, Status: types.TransactionStatusSuccess, Currency: "USD", Amount: 700},
+			{TransactionID: "TX_4", TerminalSN: "T1", Type: types.TransactionTypeSale, Currency: "USD", Amount: 300},
+			{TransactionID: "TX_5", TerminalSN: "T1", Type: types.TransactionTypeRefund, Currency: "USD", Amount: 200},
+			{TransactionID: "TX_6", TerminalSN: "T1", Type: types.TransactionTypeSale, Currency: "USD", Amount: 400},
+			{TransactionID: "TX_7", TerminalSN: "T2", Type: types.TransactionTypeSale, Currency: "USD", Amount: 900},
+			{TransactionID: "TX_8", TerminalSN: "T1", Type: types.TransactionTypeSale, Currency: "EUR", Amount: 100},
+		},
+		Remote: []*response.QueryResponse{
+			{TransactionID: "TX_3", TransactionStatus: types.TransactionStatusFail},
+			{TransactionID: "TX_9", TransactionStatus: types.TransactionStatusSuccess},
+		},
+		Terminals: []Terminal{{TerminalSN: "T1"}},
+	})
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+
+	want := []struct {
+		kind DiffKind
+		id   string
+	}{
+		{DiffAmountMismatch, "TX_2"},
+		{DiffStatusMismatch, "TX_3"},
+		{DiffMissingRemotely, "TX_4"},
+		{DiffMissingLocally, "TX_9"},
+		{DiffBatchTotalMismatch, ""},
+		{DiffBatchMissingRemotely, ""},
+	}
+	if len(report.Diffs) != len(want) {
+		t.Fatalf("Run() returned %d diffs (%+v), want %d", len(report.Diffs), report.Diffs, len(want))
+	}
+	for i, w := range want {
+		if d := report.Diffs[i]; d.Kind != w.kind || d.TransactionID != w.id {
+			t.Fatalf("diff %d = %s %s, want %s %s", i, d.Kind, d.TransactionID, w.kind, w.id)
+		}
+	}
+	// TX_5 counts as the recorded refund; the EUR batch of T1 may hold TX_8 and is not compared
+	batch := report.Diffs[4]
+	if batch.BatchNo != "B1" || batch.LocalCount != 3 || batch.RemoteCount != 3 || batch.LocalAmount != 1300 || batch.RemoteAmount != 1400 {
+		t.Fatalf("batch diff = %+v, want B1 with 3/3 transactions and 1300/1400 net", batch)
+	}
+	// T2 was not compared, so its batch B5 is not reported
+	if missing := report.Diffs[5]; missing.TerminalSN != "T1" || missing.BatchNo != "B0" || missing.LocalCount != 1 || missing.LocalAmount != 400 {
+		t.Fatalf("missing batch diff = %+v, want B0 of T1 with 1 transaction of 400", missing)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].Record.TransactionID != "TX_8" {
+		t.Fatalf("Errors = %+v, want the failed lookup of TX_8", report.Errors)
+	}
+	if report.Checked != 7 || report.Matched != 4 || report.Batches != 2 || report.OK() {
+		t.Fatalf("report counts = checked %d, matched %d, batches %d", report.Checked, report.Matched, report.Batches)
+	}
+}
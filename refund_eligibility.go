@@ -0,0 +1,218 @@
+package nexus
+
+import (
+	"fmt"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/lifecycle"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+// Refund amount components reported by errors.OverRefundError
+const (
+	RefundComponentOrder     = "order"
+	RefundComponentTip       = "tip"
+	RefundComponentTax       = "tax"
+	RefundComponentSurcharge = "surcharge"
+	RefundComponentTotal     = "total"
+)
+
+// AmountBreakdown splits an amount into its parts, in cents
+type AmountBreakdown struct {
+	Order     int64
+	Tip       int64
+	Tax       int64
+	Surcharge int64
+}
+
+// Total returns the sum of all parts
+func (b AmountBreakdown) Total() int64 {
+	return b.Order + b.Tip + b.Tax + b.Surcharge
+}
+
+func (b AmountBreakdown) add(o AmountBreakdown) AmountBreakdown {
+	return AmountBreakdown{b.Order + o.Order, b.Tip + o.Tip, b.Tax + o.Tax, b.Surcharge + o.Surcharge}
+}
+
+// remaining returns b minus o, floored at zero per part
+func (b AmountBreakdown) remaining(o AmountBreakdown) AmountBreakdown {
+	floor := func(v int64) int64 {
+		if v < 0 {
+			return 0
+		}
+		return v
+	}
+	return AmountBreakdown{floor(b.Order - o.Order), floor(b.Tip - o.Tip), floor(b.Tax - o.Tax), floor(b.Surcharge - o.Surcharge)}
+}
+
+// RefundEligibility describes how much of a transaction can still be refunded
+type RefundEligibility struct {
+	// TransactionID is the original transaction ID
+	TransactionID string
+
+	// Currency is the transaction currency
+	Currency string
+
+	// Eligible reports whether any amount can be refunded
+	Eligible bool
+
+	// Reason explains why the transaction is not eligible, empty when eligible
+	Reason string
+
+	// Voided reports whether the transaction was voided
+	Voided bool
+
+	// Settled reports whether the transaction's batch is closed; unsettled transactions can usually be voided instead
+	Settled bool
+
+	// Original is the amount of the original transaction
+	Original AmountBreakdown
+
+	// Refunded is the amount of successful and pending refunds
+	Refunded AmountBreakdown
+
+	// Refundable is the amount still refundable
+	Refundable AmountBreakdown
+}
+
+// CalculateRefundEligibility computes the refundable amounts of a transaction from its query result
+// and the query results of its known refunds
+// Refunds that failed or closed are ignored; pending refunds reserve their amount. A transaction
+// reported as fully refunded has nothing left to refund even if its refunds are not all known
+func CalculateRefundEligibility(original *response.QueryResponse, refunds []*response.QueryResponse) (*RefundEligibility, error) {
+	if original == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"original QueryResponse cannot be nil",
+			"",
+		)
+	}
+
+	e := &RefundEligibility{
+		TransactionID: original.TransactionID,
+		Voided:        original.RelatedTransactionStatus == types.RelatedTransactionStatusVoided,
+		Settled:       original.TransactionBatchStatus == types.TransactionBatchStatusC,
+	}
+	if original.Amount != nil {
+		e.Currency = original.Amount.PriceCurrency
+		e.Original = breakdownOf(original.Amount)
+	}
+
+	for _, refund := range refunds {
+		if refund == nil || refund.TransactionStatus == types.TransactionStatusFail || refund.TransactionStatus == types.TransactionStatusClosed {
+			continue
+		}
+		if refund.Amount == nil {
+			continue
+		}
+		if refund.Amount.PriceCurrency != "" && e.Currency != "" && refund.Amount.PriceCurrency != e.Currency {
+			return nil, errors.NewBusinessError(
+				constant.ErrorCodeParameterError,
+				fmt.Sprintf("refund %s currency %s differs from the original currency %s", refund.TransactionID, refund.Amount.PriceCurrency, e.Currency),
+				"",
+			)
+		}
+		e.Refunded = e.Refunded.add(breakdownOf(refund.Amount))
+	}
+	e.Refundable = e.Original.remaining(e.Refunded)
+
+	switch {
+	case original.TransactionStatus != types.TransactionStatusSuccess:
+		e.Reason = fmt.Sprintf("transaction status is %s, not S", original.TransactionStatus)
+	case e.Voided:
+		e.Reason = "transaction was voided"
+	case original.RelatedTransactionStatus == types.RelatedTransactionStatusRefunded:
+		e.Reason = "transaction was fully refunded"
+	case !lifecycle.CanRefund(original):
+		e.Reason = fmt.Sprintf("transaction type %s cannot be refunded", original.TransactionType)
+	case e.Refundable.Total() <= 0:
+		e.Reason = "no refundable amount left"
+	}
+	if e.Reason != "" {
+		e.Refundable = AmountBreakdown{}
+	}
+	e.Eligible = e.Reason == ""
+	return e, nil
+}
+
+// Check verifies a refund amount against the refundable amounts
+// It returns an *errors.OverRefundError naming the first exceeded part, or the total with a reason
+// when the transaction is not eligible
+func (e *RefundEligibility) Check(amount *common.RefundAmount) error {
+	if amount == nil || amount.OrderAmount == nil {
+		return errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"RefundAmount with OrderAmount is required",
+			"",
+		)
+	}
+	if amount.PriceCurrency != "" && e.Currency != "" && amount.PriceCurrency != e.Currency {
+		return errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("refund currency %s differs from the original currency %s", amount.PriceCurrency, e.Currency),
+			"",
+		)
+	}
+
+	requested := AmountBreakdown{
+		Order:     *amount.OrderAmount,
+		Tip:       int64Value(amount.TipAmount),
+		Tax:       int64Value(amount.TaxAmount),
+		Surcharge: int64Value(amount.SurchargeAmount),
+	}
+	if !e.Eligible {
+		return errors.NewOverRefundError(RefundComponentTotal, e.Currency, requested.Total(), 0, e.Reason)
+	}
+
+	for _, part := range []struct {
+		component             string
+		requested, refundable int64
+	}{
+		{RefundComponentOrder, requested.Order, e.Refundable.Order},
+		{RefundComponentTip, requested.Tip, e.Refundable.Tip},
+		{RefundComponentTax, requested.Tax, e.Refundable.Tax},
+		{RefundComponentSurcharge, requested.Surcharge, e.Refundable.Surcharge},
+		{RefundComponentTotal, requested.Total(), e.Refundable.Total()},
+	} {
+		if part.requested > part.refundable {
+			return errors.NewOverRefundError(part.component, e.Currency, part.requested, part.refundable, "")
+		}
+	}
+	return nil
+}
+
+// RefundAmount returns a RefundAmount for the whole refundable amount
+func (e *RefundEligibility) RefundAmount() *common.RefundAmount {
+	r := e.Refundable
+	amount := &common.RefundAmount{
+		OrderAmount:   &r.Order,
+		PriceCurrency: e.Currency,
+	}
+	if r.Tip > 0 {
+		amount.TipAmount = &r.Tip
+	}
+	if r.Tax > 0 {
+		amount.TaxAmount = &r.Tax
+	}
+	if r.Surcharge > 0 {
+		amount.SurchargeAmount = &r.Surcharge
+	}
+	return amount
+}
+
+// breakdownOf splits a transaction amount; a missing order amount is derived from the transaction amount
+func breakdownOf(a *common.Amount) AmountBreakdown {
+	b := AmountBreakdown{
+		Order:     int64Value(a.OrderAmount),
+		Tip:       int64Value(a.TipAmount),
+		Tax:       int64Value(a.TaxAmount),
+		Surcharge: int64Value(a.SurchargeAmount),
+	}
+	if a.OrderAmount == nil && a.TransAmount != nil {
+		b.Order = *a.TransAmount - b.Tip - b.Tax - b.Surcharge - int64Value(a.CashbackAmount)
+	}
+	return b
+}
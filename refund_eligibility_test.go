@@ -0,0 +1,81 @@
+package nexus
+
+import (
+	"testing"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+func queryResult(status types.TransactionStatus, order, tip int64) *response.QueryResponse {
+	return &response.QueryResponse{
+		TransactionID:     "TX",
+		TransactionType:   types.TransactionTypeSale,
+		TransactionStatus: status,
+		Amount:            &common.Amount{PriceCurrency: "USD", OrderAmount: int64Ptr(order), TipAmount: int64Ptr(tip)},
+	}
+}
+
+func TestCalculateRefundEligibility(t *testing.T) {
+	original := queryResult(types.TransactionStatusSuccess, 10000, 1500)
+	original.TransactionBatchStatus = types.TransactionBatchStatusC
+	original.RelatedTransactionStatus = types.RelatedTransactionStatusPartRefunded
+
+	e, err := CalculateRefundEligibility(original, []*response.QueryResponse{
+		queryResult(types.TransactionStatusSuccess, 3000, 500),
+		queryResult(types.TransactionStatusProcessing, 1000, 0),
+		queryResult(types.TransactionStatusFail, 6000, 1000),
+	})
+	if err != nil {
+		t.Fatalf("CalculateRefundEligibility() returned error: %v", err)
+	}
+	if !e.Eligible || !e.Settled || e.Refundable != (AmountBreakdown{Order: 6000, Tip: 1000}) {
+		t.Fatalf("unexpected eligibility: %+v", e)
+	}
+
+	cases := []struct {
+		name      string
+		order     int64
+		tip       int64
+		component string
+	}{
+		{"within limits", 6000, 1000, ""},
+		{"order exceeded", 6001, 0, RefundComponentOrder},
+		{"tip exceeded", 100, 1001, RefundComponentTip},
+	}
+	for _, tc := range cases {
+		err := e.Check(&common.RefundAmount{OrderAmount: int64Ptr(tc.order), TipAmount: int64Ptr(tc.tip), PriceCurrency: "USD"})
+		if tc.component == "" {
+			if err != nil {
+				t.Fatalf("%s: Check() returned error: %v", tc.name, err)
+			}
+			continue
+		}
+		overErr, ok := err.(*errors.OverRefundError)
+		if !ok || overErr.Component() != tc.component {
+			t.Fatalf("%s: Check() error = %v, want OverRefundError on %s", tc.name, err, tc.component)
+		}
+	}
+}
+
+func TestRefundEligibilityRejectsIneligible(t *testing.T) {
+	voided := queryResult(types.TransactionStatusSuccess, 10000, 0)
+	voided.RelatedTransactionStatus = types.RelatedTransactionStatusVoided
+	auth := queryResult(types.TransactionStatusSuccess, 10000, 0)
+	auth.TransactionType = types.TransactionTypeAuth
+
+	for _, original := range []*response.QueryResponse{voided, auth, queryResult(types.TransactionStatusFail, 10000, 0)} {
+		e, err := CalculateRefundEligibility(original, nil)
+		if err != nil {
+			t.Fatalf("CalculateRefundEligibility() returned error: %v", err)
+		}
+		if e.Eligible || e.Reason == "" || e.Refundable.Total() != 0 {
+			t.Fatalf("unexpected eligibility: %+v", e)
+		}
+		if _, ok := e.Check(&common.RefundAmount{OrderAmount: int64Ptr(1)}).(*errors.OverRefundError); !ok {
+			t.Fatal("Check() on ineligible transaction should return OverRefundError")
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package nexus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/common"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+const (
+	defaultSagaMaxAttempts   = 3
+	defaultSagaRetryInterval = time.Second
+	defaultSagaDescription   = "Order rolled back"
+
+	// compensationSuffix marks the TransactionRequestID of a compensating call
+	compensationSuffix = "-RB"
+
+	// maxTransactionRequestIDLength is the longest TransactionRequestID accepted by Void, Refund and OnlineRefund
+	maxTransactionRequestIDLength = 64
+)
+
+// SagaOutcome describes how a saga step was rolled back
+type SagaOutcome string
+
+const (
+	// SagaOutcomeCompensated means the compensating call succeeded or the transaction was already reversed
+	SagaOutcomeCompensated SagaOutcome = "COMPENSATED"
+
+	// SagaOutcomeNotNeeded means the transaction failed, was closed or was never created, so no money
+	// moved, or it is an authorization captured by a post-authorization step of the same saga
+	SagaOutcomeNotNeeded SagaOutcome = "NOT_NEEDED"
+
+	// SagaOutcomeFailed means the compensation did not succeed within the attempts; the money must be
+	// reversed manually or by a later Rollback
+	SagaOutcomeFailed SagaOutcome = "FAILED"
+)
+
+// String returns the outcome code
+func (o SagaOutcome) String() string {
+	return string(o)
+}
+
+// SagaConfig configures a Saga
+type SagaConfig struct {
+	// MaxAttempts is how many times a compensation is tried (optional, defaults to 3)
+	MaxAttempts int
+
+	// RetryInterval is the delay before the second attempt, doubled after each attempt (optional, defaults to 1s)
+	RetryInterval time.Duration
+
+	// Description is the reason sent with voids and refunds (optional, defaults to "Order rolled back")
+	Description string
+
+	// Wait configures the polling of transactions still pending at rollback (optional)
+	Wait *WaitOptions
+
+	// CallOptions are applied to every call of the saga (optional)
+	CallOptions []CallOption
+}
+
+// SagaStep is a money-moving call recorded by a Saga together with its compensation
+type SagaStep struct {
+	// Operation is the call that moved money, see constant.Operation*
+	Operation string
+
+	// Compensation is the planned compensating call. Sales and post-authorizations are refunded
+	// instead of voided once their batch is closed
+	Compensation CancelAction
+
+	// AppID is the application ID
+	AppID string
+
+	// MerchantID is the merchant ID
+	MerchantID string
+
+	// TerminalSN is the terminal serial number, empty for checkout payments
+	TerminalSN string
+
+	// TransactionRequestID is the request ID of the call
+	TransactionRequestID string
+
+	// TransactionID is the SUNBAY Nexus transaction ID, empty if the call failed before one was returned
+	TransactionID string
+
+	// OriginalTransactionID is the authorization completed by a post-authorization step, empty otherwise
+	OriginalTransactionID string
+
+	// OriginalTransactionRequestID is the request ID of the authorization completed by a post-authorization
+	// step, empty otherwise
+	OriginalTransactionRequestID string
+}
+
+// SagaStepResult is the rollback result of one step
+type SagaStepResult struct {
+	// Step is the rolled back step
+	Step SagaStep
+
+	// Outcome describes how the step was rolled back
+	Outcome SagaOutcome
+
+	// Action is the compensating call made or found, empty when none was needed
+	Action CancelAction
+
+	// TransactionRequestID is the request ID of the compensating call, derived from the step's
+	TransactionRequestID string
+
+	// Attempts is the number of compensation attempts
+	Attempts int
+
+	// Original is the last query result of the step's transaction, nil if it was never found
+	Original *response.QueryResponse
+
+	// Err is the last error, set when Outcome is SagaOutcomeFailed
+	Err error
+}
+
+// Saga records the money-moving calls of a workflow so they can be reversed if the workflow fails
+// later. Calls made through the saga are recorded unless they fail with a BusinessError; calls whose
+// outcome is unknown are recorded too, and Rollback finds out by Query whether money moved.
+// It is safe for concurrent use
+type Saga struct {
+	client     *NexusClient
+	config     SagaConfig
+	rollbackMu sync.Mutex
+	mu         sync.Mutex
+	steps      []SagaStep
+}
+
+// NewSaga creates a Saga using the client with the given configuration (nil uses defaults)
+func (c *NexusClient) NewSaga(config *SagaConfig) *Saga {
+	s := &Saga{client: c}
+	if config != nil {
+		s.config = *config
+	}
+	if s.config.MaxAttempts <= 0 {
+		s.config.MaxAttempts = defaultSagaMaxAttempts
+	}
+	if s.config.RetryInterval <= 0 {
+		s.config.RetryInterval = defaultSagaRetryInterval
+	}
+	if s.config.Description == "" {
+		s.config.Description = defaultSagaDescription
+	}
+	return s
+}
+
+// Steps returns the recorded steps not yet rolled back, in call order
+func (s *Saga) Steps() []SagaStep {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SagaStep(nil), s.steps...)
+}
+
+// Record adds a step made outside the saga, e.g. one restored after a restart
+func (s *Saga) Record(step SagaStep) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = append(s.steps, step)
+}
+
+// Sale executes a sale and records it, compensated by a void or a refund
+func (s *Saga) Sale(ctx context.Context, req *request.SaleRequest, opts ...CallOption) (*response.SaleResponse, error) {
+	resp, err := s.client.Sale(ctx, req, s.callOptions(opts)...)
+	if req != nil && recordable(err) {
+		step := SagaStep{
+			Operation:            constant.OperationSale,
+			Compensation:         CancelActionVoid,
+			AppID:                req.AppID,
+			MerchantID:           req.MerchantID,
+			TerminalSN:           req.TerminalSN,
+			TransactionRequestID: req.TransactionRequestID,
+		}
+		if resp != nil {
+			step.TransactionID = resp.TransactionID
+		}
+		s.Record(step)
+	}
+	return resp, err
+}
+
+// Auth executes an authorization and records it, compensated by a void
+func (s *Saga) Auth(ctx context.Context, req *request.AuthRequest, opts ...CallOption) (*response.AuthResponse, error) {
+	resp, err := s.client.Auth(ctx, req, s.callOptions(opts)...)
+	if req != nil && recordable(err) {
+		step := SagaStep{
+			Operation:            constant.OperationAuth,
+			Compensation:         CancelActionVoid,
+			AppID:                req.AppID,
+			MerchantID:           req.MerchantID,
+			TerminalSN:           req.TerminalSN,
+			TransactionRequestID: req.TransactionRequestID,
+		}
+		if resp != nil {
+			step.TransactionID = resp.TransactionID
+		}
+		s.Record(step)
+	}
+	return resp, err
+}
+
+// PostAuth executes a post-authorization and records it, compensated by a void or a refund
+func (s *Saga) PostAuth(ctx context.Context, req *request.PostAuthRequest, opts ...CallOption) (*response.PostAuthResponse, error) {
+	resp, err := s.client.PostAuth(ctx, req, s.callOptions(opts)...)
+	if req != nil && recordable(err) {
+		step := SagaStep{
+			Operation:                    constant.OperationPostAuth,
+			Compensation:                 CancelActionVoid,
+			AppID:                        req.AppID,
+			MerchantID:                   req.MerchantID,
+			TerminalSN:                   req.TerminalSN,
+			TransactionRequestID:         req.TransactionRequestID,
+			OriginalTransactionID:        req.OriginalTransactionID,
+			OriginalTransactionRequestID: req.OriginalTransactionRequestID,
+		}
+		if resp != nil {
+			step.TransactionID = resp.TransactionID
+		}
+		s.Record(step)
+	}
+	return resp, err
+}
+
+// DirectPayment executes a checkout payment and records it, compensated by an online refund
+func (s *Saga) DirectPayment(ctx context.Context, req *request.CheckoutDirectSaleRequest, opts ...CallOption) (*response.CheckoutDirectSaleResponse, error) {
+	resp, err := s.client.DirectPayment(ctx, req, s.callOptions(opts)...)
+	if req != nil && recordable(err) {
+		step := SagaStep{
+			Operation:            constant.OperationDirectPayment,
+			Compensation:         CancelActionOnlineRefund,
+			AppID:                req.AppID,
+			MerchantID:           req.MerchantID,
+			TransactionRequestID: req.TransactionRequestID,
+		}
+		if resp != nil {
+			step.TransactionID = resp.TransactionID
+		}
+		s.Record(step)
+	}
+	return resp, err
+}
+
+// Rollback compensates the recorded steps in reverse order and returns one result per step
+// Every step is attempted even if an earlier one fails. Compensating calls use a TransactionRequestID
+// derived from the step's, so retries and repeated rollbacks never reverse a payment twice.
+// Steps compensated or not needing compensation are removed; failed steps stay for a later Rollback
+func (s *Saga) Rollback(ctx context.Context) []*SagaStepResult {
+	s.rollbackMu.Lock()
+	defer s.rollbackMu.Unlock()
+
+	steps := s.Steps()
+	results := make([]*SagaStepResult, 0, len(steps))
+	failed := make([]SagaStep, 0)
+	for i := len(steps) - 1; i >= 0; i-- {
+		result := s.compensate(ctx, steps[i])
+		if result.Outcome == SagaOutcomeFailed {
+			failed = append([]SagaStep{steps[i]}, failed...)
+		}
+		results = append(results, result)
+	}
+
+	s.mu.Lock()
+	// Keep steps recorded while the rollback ran
+	s.steps = append(failed, s.steps[len(steps):]...)
+	s.mu.Unlock()
+	return results
+}
+
+// compensate reverses one step, retrying failures other than business errors
+func (s *Saga) compensate(ctx context.Context, step SagaStep) *SagaStepResult {
+	result := &SagaStepResult{
+		Step:                 step,
+		TransactionRequestID: CompensationRequestID(step.TransactionRequestID),
+	}
+	interval := s.config.RetryInterval
+	for {
+		result.Attempts++
+		err := s.compensateOnce(ctx, step, result)
+		if err == nil {
+			return result
+		}
+		result.Err = err
+		_, permanent := err.(*errors.BusinessError)
+		if _, over := err.(*errors.OverRefundError); over {
+			permanent = true
+		}
+		if permanent || result.Attempts >= s.config.MaxAttempts || ctx.Err() != nil {
+			result.Outcome = SagaOutcomeFailed
+			return result
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			result.Outcome = SagaOutcomeFailed
+			result.Err = ctx.Err()
+			return result
+		case <-timer.C:
+		}
+		interval *= 2
+	}
+}
+
+// compensateOnce confirms the step's transaction status and makes the compensating call when money moved
+func (s *Saga) compensateOnce(ctx context.Context, step SagaStep, result *SagaStepResult) error {
+	wait := WaitOptions{}
+	if s.config.Wait != nil {
+		wait = *s.config.Wait
+	}
+	if len(wait.CallOptions) == 0 {
+		wait.CallOptions = s.config.CallOptions
+	}
+	query := &request.QueryRequest{
+		AppID:                step.AppID,
+		MerchantID:           step.MerchantID,
+		TransactionID:        step.TransactionID,
+		TransactionRequestID: step.TransactionRequestID,
+	}
+	original, err := s.client.WaitForFinalStatus(ctx, query, &wait)
+	if original != nil {
+		result.Original = original
+	}
+	if err != nil {
+		if s.client.IsTransactionNotFound(err) && result.Original == nil {
+			// The transaction was never created
+			result.Outcome = SagaOutcomeNotNeeded
+			return nil
+		}
+		return err
+	}
+
+	if original.TransactionStatus != types.TransactionStatusSuccess {
+		result.Outcome = SagaOutcomeNotNeeded
+		return nil
+	}
+	switch original.RelatedTransactionStatus {
+	case types.RelatedTransactionStatusVoided:
+		result.Outcome, result.Action = SagaOutcomeCompensated, CancelActionVoid
+		return nil
+	case types.RelatedTransactionStatusRefunded:
+		result.Outcome, result.Action = SagaOutcomeCompensated, CancelActionRefund
+		if step.Operation == constant.OperationDirectPayment {
+			result.Action = CancelActionOnlineRefund
+		}
+		return nil
+	}
+
+	var amount *common.RefundAmount
+	switch {
+	case step.Operation == constant.OperationAuth && original.RelatedTransactionStatus == types.RelatedTransactionStatusCapture:
+		if s.captured(original) {
+			// The money moved with the post-authorization, which its own step reverses
+			result.Outcome = SagaOutcomeNotNeeded
+			return nil
+		}
+		return errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("authorization %s was captured outside the saga; cancel its post-authorization", original.TransactionID),
+			"",
+		)
+	case step.Operation == constant.OperationAuth:
+		// Cancel voids the authorization, releasing the hold including incremental amounts
+	case original.RelatedTransactionStatus == types.RelatedTransactionStatusPartRefunded:
+		return errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("transaction %s was partially refunded outside the saga; refund the rest with Cancel and its Refunds", original.TransactionID),
+			"",
+		)
+	default:
+		eligibility, err := CalculateRefundEligibility(original, nil)
+		if err != nil {
+			return err
+		}
+		amount = eligibility.RefundAmount()
+	}
+
+	channel := CancelChannelTerminal
+	if step.Operation == constant.OperationDirectPayment {
+		channel = CancelChannelCheckout
+	}
+	cancel, err := s.client.Cancel(ctx, &CancelRequest{
+		AppID:                 step.AppID,
+		MerchantID:            step.MerchantID,
+		OriginalTransactionID: original.TransactionID,
+		TransactionRequestID:  result.TransactionRequestID,
+		Channel:               channel,
+		Amount:                amount,
+		Description:           s.config.Description,
+	}, s.config.CallOptions...)
+	if err != nil {
+		return err
+	}
+	result.Outcome, result.Action = SagaOutcomeCompensated, cancel.Action
+	return nil
+}
+
+// captured reports whether a post-authorization step of the saga completes the authorization
+func (s *Saga) captured(auth *response.QueryResponse) bool {
+	for _, step := range s.Steps() {
+		if step.Operation != constant.OperationPostAuth {
+			continue
+		}
+		if (step.OriginalTransactionID != "" && step.OriginalTransactionID == auth.TransactionID) ||
+			(step.OriginalTransactionRequestID != "" && step.OriginalTransactionRequestID == auth.TransactionRequestID) {
+			return true
+		}
+	}
+	return false
+}
+
+// callOptions returns the saga's call options followed by opts
+func (s *Saga) callOptions(opts []CallOption) []CallOption {
+	if len(s.config.CallOptions) == 0 {
+		return opts
+	}
+	return append(append([]CallOption(nil), s.config.CallOptions...), opts...)
+}
+
+// recordable reports whether a call may have moved money; a BusinessError means it was rejected
+func recordable(err error) bool {
+	_, rejected := err.(*errors.BusinessError)
+	return !rejected
+}
+
+// CompensationRequestID derives the TransactionRequestID of the call compensating the request
+// transactionRequestID. The result is stable, so a retried compensation is idempotent. IDs that would
+// exceed 64 characters are shortened with a hash of the original ID
+func CompensationRequestID(transactionRequestID string) string {
+	id := transactionRequestID + compensationSuffix
+	if len(id) <= maxTransactionRequestIDLength {
+		return id
+	}
+	sum := sha256.Sum256([]byte(transactionRequestID))
+	hash := hex.EncodeToString(sum[:])[:12]
+	keep := maxTransactionRequestIDLength - len(hash) - len(compensationSuffix) - 1
+	return transactionRequestID[:keep] + "-" + hash + compensationSuffix
+}
@@ -0,0 +1,173 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+)
+
+func TestSagaRollback(t *testing.T) {
+	transactions := map[string]string{
+		"SALE_1": `"transactionId":"TX_SALE","transactionType":"SALE","transactionStatus":"S","terminalSn":"T1","transactionBatchStatus":"U","amount":{"priceCurrency":"USD","orderAmount":1000}`,
+		"AUTH_1": `"transactionId":"TX_AUTH","transactionType":"AUTH","transactionStatus":"F","terminalSn":"T1"`,
+		"DP_1":   `"transactionId":"TX_DP","transactionType":"SALE","transactionStatus":"S","transactionBatchStatus":"U","amount":{"priceCurrency":"USD","orderAmount":500}`,
+	}
+	ids := map[string]string{"TX_SALE": "SALE_1", "TX_AUTH": "AUTH_1", "TX_DP": "DP_1"}
+
+	voids := 0
+	var compensations []string
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			TransactionRequestID string `json:"transactionRequestId"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		switch r.URL.Path {
+		case constant.PathQuery:
+			reqID := r.URL.Query().Get("transactionRequestId")
+			if txID := r.URL.Query().Get("transactionId"); txID != "" {
+				reqID = ids[txID]
+			}
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{` + transactions[reqID] + `}}`))
+		case constant.PathSale, constant.PathAuth, constant.PathCheckoutSale:
+			txID := "TX_" + strings.TrimSuffix(body.TransactionRequestID, "_1")
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"` + txID + `","transactionStatus":"P"}}`))
+		case constant.PathVoid:
+			voids++
+			if voids == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			compensations = append(compensations, "void:"+body.TransactionRequestID)
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_VOID","transactionStatus":"S"}}`))
+		case constant.PathCheckoutRefund:
+			compensations = append(compensations, "online_refund:"+body.TransactionRequestID)
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_REFUND","transactionStatus":"S"}}`))
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}, Config{})
+
+	saga := client.NewSaga(&SagaConfig{
+		RetryInterval: time.Millisecond,
+		CallOptions:   []CallOption{WithMaxRetries(0)},
+	})
+	ctx := context.Background()
+	if _, err := saga.Sale(ctx, &request.SaleRequest{TransactionRequestID: "SALE_1", TerminalSN: "T1"}); err != nil {
+		t.Fatalf("Sale() returned error: %v", err)
+	}
+	if _, err := saga.Auth(ctx, &request.AuthRequest{TransactionRequestID: "AUTH_1", TerminalSN: "T1"}); err != nil {
+		t.Fatalf("Auth() returned error: %v", err)
+	}
+	if _, err := saga.DirectPayment(ctx, &request.CheckoutDirectSaleRequest{TransactionRequestID: "DP_1"}); err != nil {
+		t.Fatalf("DirectPayment() returned error: %v", err)
+	}
+
+	results := saga.Rollback(ctx)
+	want := []struct {
+		operation string
+		outcome   SagaOutcome
+		action    CancelAction
+		attempts  int
+	}{
+		{constant.OperationDirectPayment, SagaOutcomeCompensated, CancelActionOnlineRefund, 1},
+		{constant.OperationAuth, SagaOutcomeNotNeeded, "", 1},
+		{constant.OperationSale, SagaOutcomeCompensated, CancelActionVoid, 2},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Rollback() returned %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Step.Operation != w.operation || r.Outcome != w.outcome || r.Action != w.action || r.Attempts != w.attempts {
+			t.Fatalf("result %d = %s %s %s after %d attempts (err %v), want %s %s %s after %d",
+				i, r.Step.Operation, r.Outcome, r.Action, r.Attempts, r.Err, w.operation, w.outcome, w.action, w.attempts)
+		}
+	}
+	if got := strings.Join(compensations, ","); got != "online_refund:DP_1-RB,void:SALE_1-RB" {
+		t.Fatalf("compensations = %s, want online_refund:DP_1-RB,void:SALE_1-RB", got)
+	}
+	if steps := saga.Steps(); len(steps) != 0 {
+		t.Fatalf("Steps() after rollback = %v, want none", steps)
+	}
+}
+
+func TestCompensationRequestID(t *testing.T) {
+	if got := CompensationRequestID("ORDER_1"); got != "ORDER_1-RB" {
+		t.Fatalf("CompensationRequestID() = %q, want %q", got, "ORDER_1-RB")
+	}
+
+	long := strings.Repeat("A", 64)
+	got := CompensationRequestID(long)
+	if len(got) != 64 || got != CompensationRequestID(long) || !strings.HasSuffix(got, "-RB") {
+		t.Fatalf("CompensationRequestID(64 chars) = %q, want a stable 64 character ID", got)
+	}
+	if got == CompensationRequestID(strings.Repeat("A", 63)+"B") {
+		t.Fatal("CompensationRequestID() should differ for IDs sharing a prefix")
+	}
+}
+
+func TestSagaRollbackCapturedAuthorization(t *testing.T) {
+	transactions := map[string]string{
+		"TX_AUTH": `"transactionId":"TX_AUTH","transactionRequestId":"AUTH_1","transactionType":"AUTH","transactionStatus":"S","terminalSn":"T1",` +
+			`"transactionBatchStatus":"U","relatedTransactionStatus":"CAPTURE","amount":{"priceCurrency":"USD","orderAmount":1000}`,
+		"TX_CAP": `"transactionId":"TX_CAP","transactionRequestId":"CAP_1","transactionType":"POST_AUTH","transactionStatus":"S","terminalSn":"T1",` +
+			`"transactionBatchStatus":"C","amount":{"priceCurrency":"USD","orderAmount":900,"tipAmount":50}`,
+	}
+	var refunded *request.RefundRequest
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathAuth:
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_AUTH","transactionStatus":"S"}}`))
+		case constant.PathPostAuth:
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_CAP","transactionStatus":"S"}}`))
+		case constant.PathQuery:
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{` + transactions[r.URL.Query().Get("transactionId")] + `}}`))
+		case constant.PathRefund:
+			refunded = &request.RefundRequest{}
+			_ = json.NewDecoder(r.Body).Decode(refunded)
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_REFUND","transactionStatus":"S"}}`))
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}, Config{})
+	ctx := context.Background()
+
+	saga := client.NewSaga(&SagaConfig{RetryInterval: time.Millisecond})
+	if _, err := saga.Auth(ctx, &request.AuthRequest{TransactionRequestID: "AUTH_1", TerminalSN: "T1"}); err != nil {
+		t.Fatalf("Auth() returned error: %v", err)
+	}
+	if _, err := saga.PostAuth(ctx, &request.PostAuthRequest{TransactionRequestID: "CAP_1", OriginalTransactionRequestID: "AUTH_1", TerminalSN: "T1"}); err != nil {
+		t.Fatalf("PostAuth() returned error: %v", err)
+	}
+
+	results := saga.Rollback(ctx)
+	if len(results) != 2 {
+		t.Fatalf("Rollback() returned %d results, want 2", len(results))
+	}
+	if r := results[0]; r.Outcome != SagaOutcomeCompensated || r.Action != CancelActionRefund {
+		t.Fatalf("post-authorization result = %s %s (err %v), want COMPENSATED REFUND", r.Outcome, r.Action, r.Err)
+	}
+	if refunded == nil || refunded.Amount == nil || *refunded.Amount.OrderAmount != 900 || *refunded.Amount.TipAmount != 50 {
+		t.Fatalf("refund request = %+v, want the remaining 900 + 50", refunded)
+	}
+	if r := results[1]; r.Outcome != SagaOutcomeNotNeeded || r.Action != "" {
+		t.Fatalf("captured authorization result = %s %s (err %v), want NOT_NEEDED", r.Outcome, r.Action, r.Err)
+	}
+
+	// An authorization captured outside the saga is not voided
+	saga = client.NewSaga(&SagaConfig{RetryInterval: time.Millisecond})
+	if _, err := saga.Auth(ctx, &request.AuthRequest{TransactionRequestID: "AUTH_1", TerminalSN: "T1"}); err != nil {
+		t.Fatalf("Auth() returned error: %v", err)
+	}
+	results = saga.Rollback(ctx)
+	if r := results[0]; r.Outcome != SagaOutcomeFailed || r.Attempts != 1 || r.Err == nil {
+		t.Fatalf("result = %s after %d attempts (err %v), want FAILED after 1", r.Outcome, r.Attempts, r.Err)
+	}
+}
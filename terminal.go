@@ -0,0 +1,280 @@
+package nexus
+
+import (
+	"context"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+const (
+	defaultTerminalTimeout        = 3 * time.Minute
+	defaultTerminalCleanupTimeout = time.Minute
+	defaultAbortDescription       = "Transaction timed out"
+)
+
+// TerminalOutcome describes how a terminal transaction ended
+type TerminalOutcome string
+
+const (
+	// TerminalOutcomeCompleted means the transaction reached a final status before the deadline
+	TerminalOutcomeCompleted TerminalOutcome = "COMPLETED"
+
+	// TerminalOutcomeAborted means the transaction was aborted, after the deadline or a failed status query,
+	// and confirmed closed, failed or never created (see Config.TransactionNotFoundCodes)
+	TerminalOutcomeAborted TerminalOutcome = "ABORTED"
+
+	// TerminalOutcomeCompletedDespiteAbort means the transaction was aborted but succeeded before the
+	// abort took effect; money moved and the caller must keep or reverse it
+	TerminalOutcomeCompletedDespiteAbort TerminalOutcome = "COMPLETED_DESPITE_ABORT"
+
+	// TerminalOutcomeUnresolved means the transaction was aborted and its final status could not be confirmed
+	TerminalOutcomeUnresolved TerminalOutcome = "UNRESOLVED"
+)
+
+// String returns the outcome code
+func (o TerminalOutcome) String() string {
+	return string(o)
+}
+
+// TerminalOptions configures RunTerminalSale, RunTerminalAuth and RunTerminalRefund
+type TerminalOptions struct {
+	// Timeout is how long to wait for the cardholder before aborting (optional, defaults to 3m)
+	// The deadline of ctx also applies; cancelling ctx aborts the transaction as well
+	Timeout time.Duration
+
+	// CleanupTimeout bounds the abort and the confirming queries after the deadline (optional, defaults to 1m)
+	// Cleanup runs even when ctx is already cancelled
+	CleanupTimeout time.Duration
+
+	// AbortDescription is the abort reason sent to the terminal (optional, defaults to "Transaction timed out")
+	AbortDescription string
+
+	// Wait configures the status polling (optional)
+	Wait *WaitOptions
+
+	// CallOptions are applied to every call of the flow (optional)
+	CallOptions []CallOption
+}
+
+// TerminalResult is the result of a terminal transaction flow
+type TerminalResult struct {
+	// Outcome describes how the transaction ended
+	Outcome TerminalOutcome
+
+	// TransactionRequestID is the request ID of the transaction
+	TransactionRequestID string
+
+	// Transaction is the last query result, nil if the transaction was never created or never queried
+	Transaction *response.QueryResponse
+
+	// Abort is the abort response, nil if no abort was sent or it failed
+	Abort *response.AbortResponse
+}
+
+// terminalFlow identifies the transaction driven by runTerminal
+type terminalFlow struct {
+	appID                string
+	merchantID           string
+	terminalSN           string
+	transactionRequestID string
+	send                 func(ctx context.Context) error
+}
+
+// RunTerminalSale sends a sale to the terminal and waits for its final status
+// If the timeout or the deadline of ctx hits first, ctx is cancelled or the status cannot be queried,
+// the sale is aborted by its TransactionRequestID and its closure is confirmed by Query; see TerminalOutcome for the possible results
+func (c *NexusClient) RunTerminalSale(ctx context.Context, req *request.SaleRequest, opts *TerminalOptions) (*TerminalResult, error) {
+	if req == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"SaleRequest cannot be nil",
+			"",
+		)
+	}
+	return c.runTerminal(ctx, terminalFlow{
+		appID:                req.AppID,
+		merchantID:           req.MerchantID,
+		terminalSN:           req.TerminalSN,
+		transactionRequestID: req.TransactionRequestID,
+		send: func(ctx context.Context) error {
+			_, err := c.Sale(ctx, req, terminalCallOptions(opts)...)
+			return err
+		},
+	}, opts)
+}
+
+// RunTerminalAuth sends an authorization to the terminal and waits for its final status
+// It aborts on timeout like RunTerminalSale
+func (c *NexusClient) RunTerminalAuth(ctx context.Context, req *request.AuthRequest, opts *TerminalOptions) (*TerminalResult, error) {
+	if req == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"AuthRequest cannot be nil",
+			"",
+		)
+	}
+	return c.runTerminal(ctx, terminalFlow{
+		appID:                req.AppID,
+		merchantID:           req.MerchantID,
+		terminalSN:           req.TerminalSN,
+		transactionRequestID: req.TransactionRequestID,
+		send: func(ctx context.Context) error {
+			_, err := c.Auth(ctx, req, terminalCallOptions(opts)...)
+			return err
+		},
+	}, opts)
+}
+
+// RunTerminalRefund sends a refund to the terminal and waits for its final status
+// It aborts on timeout like RunTerminalSale
+func (c *NexusClient) RunTerminalRefund(ctx context.Context, req *request.RefundRequest, opts *TerminalOptions) (*TerminalResult, error) {
+	if req == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"RefundRequest cannot be nil",
+			"",
+		)
+	}
+	return c.runTerminal(ctx, terminalFlow{
+		appID:                req.AppID,
+		merchantID:           req.MerchantID,
+		terminalSN:           req.TerminalSN,
+		transactionRequestID: req.TransactionRequestID,
+		send: func(ctx context.Context) error {
+			_, err := c.Refund(ctx, req, terminalCallOptions(opts)...)
+			return err
+		},
+	}, opts)
+}
+
+// runTerminal sends the transaction, waits for its final status and aborts it when the deadline hits
+// A BusinessError from sending is returned as is since nothing reached the terminal
+func (c *NexusClient) runTerminal(ctx context.Context, flow terminalFlow, opts *TerminalOptions) (*TerminalResult, error) {
+	if flow.transactionRequestID == "" {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"TransactionRequestID cannot be empty",
+			"",
+		)
+	}
+	if flow.terminalSN == "" {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"TerminalSN cannot be empty",
+			"",
+		)
+	}
+	if opts == nil {
+		opts = &TerminalOptions{}
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultTerminalTimeout
+	}
+
+	result := &TerminalResult{TransactionRequestID: flow.transactionRequestID}
+	query := &request.QueryRequest{
+		AppID:                flow.appID,
+		MerchantID:           flow.merchantID,
+		TransactionRequestID: flow.transactionRequestID,
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := flow.send(waitCtx)
+	switch err.(type) {
+	case nil:
+		var final *response.QueryResponse
+		final, err = c.WaitForFinalStatus(waitCtx, query, terminalWaitOptions(opts))
+		result.Transaction = final
+		if err == nil {
+			result.Outcome = TerminalOutcomeCompleted
+			return result, nil
+		}
+		// The status is unknown, whether the deadline hit or the query failed; the terminal may still
+		// be prompting the customer
+	case *errors.NetworkError:
+		// The request may have reached the terminal; abort to be sure
+	default:
+		if waitCtx.Err() == nil {
+			return nil, err
+		}
+	}
+
+	return c.abortTerminal(ctx, flow, query, result, opts)
+}
+
+// abortTerminal aborts the transaction and confirms its final status
+func (c *NexusClient) abortTerminal(ctx context.Context, flow terminalFlow, query *request.QueryRequest, result *TerminalResult, opts *TerminalOptions) (*TerminalResult, error) {
+	cleanupTimeout := opts.CleanupTimeout
+	if cleanupTimeout <= 0 {
+		cleanupTimeout = defaultTerminalCleanupTimeout
+	}
+	description := opts.AbortDescription
+	if description == "" {
+		description = defaultAbortDescription
+	}
+
+	// The caller's context may already be cancelled; cleanup gets its own budget
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+
+	abort, err := c.Abort(cleanupCtx, &request.AbortRequest{
+		AppID:                        flow.appID,
+		MerchantID:                   flow.merchantID,
+		OriginalTransactionRequestID: flow.transactionRequestID,
+		TerminalSN:                   flow.terminalSN,
+		Description:                  description,
+	}, terminalCallOptions(opts)...)
+	if err == nil {
+		result.Abort = abort
+	}
+	// An abort rejected because the transaction already completed is expected; the query below tells
+
+	final, err := c.WaitForFinalStatus(cleanupCtx, query, terminalWaitOptions(opts))
+	if final != nil {
+		result.Transaction = final
+	}
+	if err != nil {
+		if c.IsTransactionNotFound(err) && result.Transaction == nil {
+			// The transaction was never created
+			result.Outcome = TerminalOutcomeAborted
+			return result, nil
+		}
+		result.Outcome = TerminalOutcomeUnresolved
+		return result, err
+	}
+
+	if final.TransactionStatus == types.TransactionStatusSuccess {
+		result.Outcome = TerminalOutcomeCompletedDespiteAbort
+	} else {
+		result.Outcome = TerminalOutcomeAborted
+	}
+	return result, nil
+}
+
+// terminalCallOptions returns the call options of the flow
+func terminalCallOptions(opts *TerminalOptions) []CallOption {
+	if opts == nil {
+		return nil
+	}
+	return opts.CallOptions
+}
+
+// terminalWaitOptions returns the polling options of the flow with the call options applied
+func terminalWaitOptions(opts *TerminalOptions) *WaitOptions {
+	wait := WaitOptions{}
+	if opts.Wait != nil {
+		wait = *opts.Wait
+	}
+	if len(wait.CallOptions) == 0 {
+		wait.CallOptions = opts.CallOptions
+	}
+	return &wait
+}
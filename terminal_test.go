@@ -0,0 +1,157 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+)
+
+func TestRunTerminalSale(t *testing.T) {
+	cases := []struct {
+		name        string
+		statusAfter string
+		completes   bool
+		want        TerminalOutcome
+	}{
+		{"completed", "S", true, TerminalOutcomeCompleted},
+		{"aborted on timeout", "C", false, TerminalOutcomeAborted},
+		{"succeeded before abort", "S", false, TerminalOutcomeCompletedDespiteAbort},
+	}
+
+	for _, tc := range cases {
+		var mu sync.Mutex
+		aborted := false
+		var abortReq request.AbortRequest
+		client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch r.URL.Path {
+			case constant.PathSale:
+				_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionRequestId":"REQ_1","transactionStatus":"P"}}`))
+			case constant.PathAbort:
+				aborted = true
+				_ = json.NewDecoder(r.Body).Decode(&abortReq)
+				_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"originalTransactionId":"TX_1"}}`))
+			case constant.PathQuery:
+				status := "P"
+				if aborted || tc.completes {
+					status = tc.statusAfter
+				}
+				_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionRequestId":"REQ_1","transactionStatus":"` + status + `"}}`))
+			}
+		}, Config{})
+
+		result, err := client.RunTerminalSale(context.Background(), &request.SaleRequest{
+			AppID:                "app",
+			MerchantID:           "mch",
+			TransactionRequestID: "REQ_1",
+			TerminalSN:           "T1",
+		}, &TerminalOptions{
+			Timeout: 30 * time.Millisecond,
+			Wait:    &WaitOptions{InitialInterval: 5 * time.Millisecond, MaxInterval: 5 * time.Millisecond},
+		})
+		if err != nil {
+			t.Fatalf("%s: RunTerminalSale() returned error: %v", tc.name, err)
+		}
+		if result.Outcome != tc.want {
+			t.Fatalf("%s: Outcome = %s, want %s", tc.name, result.Outcome, tc.want)
+		}
+		if aborted != !tc.completes {
+			t.Fatalf("%s: aborted = %v, want %v", tc.name, aborted, !tc.completes)
+		}
+		if aborted && (abortReq.OriginalTransactionRequestID != "REQ_1" || abortReq.TerminalSN != "T1" || result.Abort == nil) {
+			t.Fatalf("%s: unexpected abort request %+v", tc.name, abortReq)
+		}
+	}
+}
+
+func TestRunTerminalSaleAbortsWhenCallerCancels(t *testing.T) {
+	aborted := make(chan struct{}, 1)
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constant.PathSale:
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionStatus":"P"}}`))
+		case constant.PathAbort:
+			aborted <- struct{}{}
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{}}`))
+		case constant.PathQuery:
+			status := "P"
+			if len(aborted) > 0 {
+				status = "C"
+			}
+			_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionStatus":"` + status + `"}}`))
+		}
+	}, Config{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	result, err := client.RunTerminalSale(ctx, &request.SaleRequest{TransactionRequestID: "REQ_1", TerminalSN: "T1"}, &TerminalOptions{
+		Wait: &WaitOptions{InitialInterval: 5 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("RunTerminalSale() returned error: %v", err)
+	}
+	if result.Outcome != TerminalOutcomeAborted || len(aborted) != 1 {
+		t.Fatalf("Outcome = %s, aborts = %d; want ABORTED after one abort", result.Outcome, len(aborted))
+	}
+}
+
+func TestRunTerminalSaleAbortsWhenStatusUnknown(t *testing.T) {
+	cases := []struct {
+		name      string
+		saleFails bool
+		queryCode string
+		want      TerminalOutcome
+	}{
+		{"status query rejected", false, "A401", TerminalOutcomeAborted},
+		{"not found after network error", true, "T404", TerminalOutcomeAborted},
+		{"lookup rejected after network error", true, "A401", TerminalOutcomeUnresolved},
+	}
+
+	for _, tc := range cases {
+		var mu sync.Mutex
+		aborted := false
+		client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch r.URL.Path {
+			case constant.PathSale:
+				if tc.saleFails {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionStatus":"P"}}`))
+			case constant.PathAbort:
+				aborted = true
+				_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{}}`))
+			case constant.PathQuery:
+				if aborted && !tc.saleFails {
+					_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionStatus":"C"}}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"code":"` + tc.queryCode + `","msg":"query failed"}`))
+			}
+		}, Config{TransactionNotFoundCodes: []string{"T404"}})
+
+		result, err := client.RunTerminalSale(context.Background(), &request.SaleRequest{TransactionRequestID: "REQ_1", TerminalSN: "T1"}, &TerminalOptions{
+			Timeout:     time.Second,
+			Wait:        &WaitOptions{InitialInterval: 5 * time.Millisecond},
+			CallOptions: []CallOption{WithMaxRetries(0)},
+		})
+		if !aborted {
+			t.Fatalf("%s: no abort was sent", tc.name)
+		}
+		if result == nil || result.Outcome != tc.want {
+			t.Fatalf("%s: result = %+v (err %v), want %s", tc.name, result, err, tc.want)
+		}
+		if (err != nil) != (tc.want == TerminalOutcomeUnresolved) {
+			t.Fatalf("%s: RunTerminalSale() error = %v", tc.name, err)
+		}
+	}
+}
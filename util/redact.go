@@ -0,0 +1,49 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// RedactedValue replaces the value of a redacted field
+const RedactedValue = "[REDACTED]"
+
+// redactedFields are the JSON fields holding card data or customer details
+var redactedFields = map[string]bool{
+	"cardEncryptedData": true,
+	"customerEmail":     true,
+	"customerName":      true,
+	"billingAddress":    true,
+	"shippingAddress":   true,
+}
+
+// RedactJSON returns a JSON document with card data and customer details replaced by RedactedValue
+// at any depth, e.g. before it is logged or persisted. Numbers keep their exact representation
+func RedactJSON(body []byte) ([]byte, error) {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redact(doc))
+}
+
+// redact replaces the redacted fields of a decoded JSON value in place
+func redact(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for name, field := range value {
+			if redactedFields[name] {
+				value[name] = RedactedValue
+			} else {
+				value[name] = redact(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redact(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,114 @@
+package nexus
+
+import (
+	"context"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/lifecycle"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+)
+
+const (
+	defaultWaitInitialInterval = time.Second
+	defaultWaitMaxInterval     = 10 * time.Second
+	defaultWaitMultiplier      = 1.5
+)
+
+// WaitOptions configures WaitForFinalStatus
+type WaitOptions struct {
+	// InitialInterval is the delay before the second query (optional, defaults to 1s)
+	InitialInterval time.Duration
+
+	// MaxInterval caps the delay between queries (optional, defaults to 10s)
+	MaxInterval time.Duration
+
+	// Multiplier grows the delay after each query (optional, defaults to 1.5)
+	Multiplier float64
+
+	// Updates receives the query result each time the transaction status changes, including the
+	// final one (optional). Sends block until received or ctx is done; the channel is not closed
+	Updates chan<- *response.QueryResponse
+
+	// CallOptions are applied to every Query call (optional)
+	CallOptions []CallOption
+}
+
+// WaitForFinalStatus polls Query with backoff until the transaction reaches a final status
+// (S, F or C) and returns the final query result
+// The transaction is identified by req.TransactionID or req.TransactionRequestID. Network failures
+// are retried on the next poll; business errors end the wait. When ctx ends first, the last query
+// result (nil if none succeeded) is returned together with ctx.Err()
+func (c *NexusClient) WaitForFinalStatus(ctx context.Context, req *request.QueryRequest, opts *WaitOptions) (*response.QueryResponse, error) {
+	if req == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"QueryRequest cannot be nil",
+			"",
+		)
+	}
+	if req.TransactionID == "" && req.TransactionRequestID == "" {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"TransactionID or TransactionRequestID is required",
+			"",
+		)
+	}
+	if opts == nil {
+		opts = &WaitOptions{}
+	}
+
+	interval := opts.InitialInterval
+	if interval <= 0 {
+		interval = defaultWaitInitialInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultWaitMaxInterval
+	}
+	multiplier := opts.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultWaitMultiplier
+	}
+
+	var last *response.QueryResponse
+	for {
+		resp, err := c.Query(ctx, req, opts.CallOptions...)
+		switch err.(type) {
+		case nil:
+			if last == nil || resp.TransactionStatus != last.TransactionStatus {
+				if opts.Updates != nil {
+					select {
+					case opts.Updates <- resp:
+					case <-ctx.Done():
+						return resp, ctx.Err()
+					}
+				}
+			}
+			last = resp
+			if lifecycle.IsFinal(resp) {
+				return resp, nil
+			}
+		case *errors.BusinessError:
+			return last, err
+		default:
+			if ctx.Err() != nil {
+				return last, ctx.Err()
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last, ctx.Err()
+		case <-timer.C:
+		}
+		interval = time.Duration(float64(interval) * multiplier)
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
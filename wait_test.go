@@ -0,0 +1,65 @@
+package nexus
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/request"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+func TestWaitForFinalStatus(t *testing.T) {
+	statuses := []string{"I", "P", "P", "", "S"}
+	queries := 0
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[queries]
+		queries++
+		if status == "" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionStatus":"` + status + `"}}`))
+	}, Config{})
+
+	updates := make(chan *response.QueryResponse, len(statuses))
+	resp, err := client.WaitForFinalStatus(context.Background(), &request.QueryRequest{TransactionID: "TX_1"}, &WaitOptions{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     2 * time.Millisecond,
+		Updates:         updates,
+		CallOptions:     []CallOption{WithMaxRetries(0)},
+	})
+	if err != nil {
+		t.Fatalf("WaitForFinalStatus() returned error: %v", err)
+	}
+	if resp.TransactionStatus != types.TransactionStatusSuccess || queries != len(statuses) {
+		t.Fatalf("status = %s after %d queries, want S after %d", resp.TransactionStatus, queries, len(statuses))
+	}
+
+	close(updates)
+	var seen []types.TransactionStatus
+	for u := range updates {
+		seen = append(seen, u.TransactionStatus)
+	}
+	if len(seen) != 3 || seen[0] != "I" || seen[1] != "P" || seen[2] != "S" {
+		t.Fatalf("updates = %v, want [I P S]", seen)
+	}
+}
+
+func TestWaitForFinalStatusStopsAtDeadline(t *testing.T) {
+	client := newTestNexusClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":"0","msg":"success","data":{"transactionId":"TX_1","transactionStatus":"P"}}`))
+	}, Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	resp, err := client.WaitForFinalStatus(ctx, &request.QueryRequest{TransactionRequestID: "REQ_1"}, &WaitOptions{InitialInterval: 5 * time.Millisecond})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("WaitForFinalStatus() error = %v, want context.DeadlineExceeded", err)
+	}
+	if resp == nil || resp.TransactionStatus != types.TransactionStatusProcessing {
+		t.Fatalf("last response = %+v, want status P", resp)
+	}
+}
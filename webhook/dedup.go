@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDedupCapacity = 10000
+	defaultDedupTTL      = 24 * time.Hour
+	defaultDedupLease    = 5 * time.Minute
+)
+
+// DedupStatus is the processing state of a notification key
+type DedupStatus int
+
+const (
+	// DedupNew means the key was not seen before and is now claimed by the caller
+	DedupNew DedupStatus = iota
+
+	// DedupInProgress means another delivery of the same event is being processed
+	DedupInProgress
+
+	// DedupDone means the event was already processed successfully
+	DedupDone
+)
+
+// String returns the status name
+func (s DedupStatus) String() string {
+	switch s {
+	case DedupNew:
+		return "NEW"
+	case DedupInProgress:
+		return "IN_PROGRESS"
+	case DedupDone:
+		return "DONE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// DedupStore records which notifications were processed so that at-least-once deliveries
+// are handled exactly once from the application's point of view
+// Implementations must be safe for concurrent use
+type DedupStore interface {
+	// Begin claims the key for processing unless it is already claimed or done
+	// A claim that is neither completed nor released expires after the store's lease
+	Begin(ctx context.Context, key string) (DedupStatus, error)
+
+	// Complete marks a claimed key as processed
+	Complete(ctx context.Context, key string) error
+
+	// Release drops a claim after failed processing so a redelivery is processed again
+	Release(ctx context.Context, key string) error
+}
+
+// DedupKey returns the deduplication key of an event: the transaction ID and status for
+// transaction and checkout events, the terminal and batch number for batch close events
+// It returns an empty string for events that cannot be identified
+func DedupKey(event *Event) string {
+	switch {
+	case event.Transaction != nil && event.Transaction.TransactionID != "":
+		return "tx:" + event.Transaction.TransactionID + ":" + event.Transaction.TransactionStatus.String()
+	case event.BatchClose != nil && event.BatchClose.BatchNo != "":
+		return "batch:" + event.BatchClose.TerminalSN + ":" + event.BatchClose.BatchNo
+	default:
+		return ""
+	}
+}
+
+// MemoryDedupConfig holds the configuration for creating a MemoryDedupStore
+type MemoryDedupConfig struct {
+	// Capacity is the maximum number of remembered keys; the least recently used key is evicted first
+	// (optional, defaults to 10000)
+	Capacity int
+
+	// TTL is how long a processed key is remembered (optional, defaults to 24h)
+	TTL time.Duration
+
+	// Lease is how long an unfinished claim blocks redeliveries (optional, defaults to 5m)
+	Lease time.Duration
+}
+
+// MemoryDedupStore is an in-process DedupStore with LRU eviction and TTL expiry
+// It does not survive restarts and is not shared between instances; use SQLDedupStore for that
+type MemoryDedupStore struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	lease    time.Duration
+	entries  map[string]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+type dedupEntry struct {
+	key     string
+	status  DedupStatus
+	expires time.Time
+}
+
+// NewMemoryDedupStore creates an in-memory DedupStore with the given configuration (nil uses defaults)
+func NewMemoryDedupStore(config *MemoryDedupConfig) *MemoryDedupStore {
+	if config == nil {
+		config = &MemoryDedupConfig{}
+	}
+
+	capacity := config.Capacity
+	if capacity <= 0 {
+		capacity = defaultDedupCapacity
+	}
+	ttl := config.TTL
+	if ttl == 0 {
+		ttl = defaultDedupTTL
+	}
+	lease := config.Lease
+	if lease == 0 {
+		lease = defaultDedupLease
+	}
+
+	return &MemoryDedupStore{
+		capacity: capacity,
+		ttl:      ttl,
+		lease:    lease,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Begin implements DedupStore
+func (s *MemoryDedupStore) Begin(ctx context.Context, key string) (DedupStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if elem, ok := s.entries[key]; ok {
+		entry := elem.Value.(*dedupEntry)
+		if now.Before(entry.expires) {
+			s.order.MoveToFront(elem)
+			return entry.status, nil
+		}
+		s.remove(elem)
+	}
+
+	s.entries[key] = s.order.PushFront(&dedupEntry{
+		key:     key,
+		status:  DedupInProgress,
+		expires: now.Add(s.lease),
+	})
+	for s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+	return DedupNew, nil
+}
+
+// Complete implements DedupStore
+func (s *MemoryDedupStore) Complete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expires := s.now().Add(s.ttl)
+	if elem, ok := s.entries[key]; ok {
+		entry := elem.Value.(*dedupEntry)
+		entry.status = DedupDone
+		entry.expires = expires
+		s.order.MoveToFront(elem)
+		return nil
+	}
+	// The claim was evicted meanwhile; remember the outcome anyway
+	s.entries[key] = s.order.PushFront(&dedupEntry{key: key, status: DedupDone, expires: expires})
+	for s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+// Release implements DedupStore
+func (s *MemoryDedupStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok && elem.Value.(*dedupEntry).status == DedupInProgress {
+		s.remove(elem)
+	}
+	return nil
+}
+
+// Len returns the number of remembered keys, including expired ones not yet evicted
+func (s *MemoryDedupStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *MemoryDedupStore) remove(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.entries, elem.Value.(*dedupEntry).key)
+}
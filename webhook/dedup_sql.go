@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+)
+
+const (
+	defaultDedupTable = "nexus_webhook_dedup"
+
+	dedupStatusInProgress = "P"
+	dedupStatusDone       = "D"
+)
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// SQLDedupConfig holds the configuration for creating a SQLDedupStore
+type SQLDedupConfig struct {
+	// DB is the database handle (required)
+	DB *sql.DB
+
+	// Table is the table name (optional, defaults to nexus_webhook_dedup)
+	// The table must have the layout created by SQLDedupStore.CreateTable
+	Table string
+
+	// NumberedPlaceholders selects $1, $2, ... placeholders (PostgreSQL) instead of ? (MySQL, SQLite)
+	NumberedPlaceholders bool
+
+	// TTL is how long a processed key is remembered (optional, defaults to 24h)
+	TTL time.Duration
+
+	// Lease is how long an unfinished claim blocks redeliveries (optional, defaults to 5m)
+	Lease time.Duration
+}
+
+// SQLDedupStore is a DedupStore backed by database/sql, shared by all instances using the same table
+// Rows are keyed by the event key; the primary key constraint arbitrates concurrent deliveries
+type SQLDedupStore struct {
+	db       *sql.DB
+	table    string
+	numbered bool
+	ttl      time.Duration
+	lease    time.Duration
+	now      func() time.Time
+}
+
+// NewSQLDedupStore creates a database/sql DedupStore with the given configuration
+func NewSQLDedupStore(config *SQLDedupConfig) (*SQLDedupStore, error) {
+	if config == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"SQLDedupConfig cannot be nil",
+			"",
+		)
+	}
+	if config.DB == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"DB cannot be nil",
+			"",
+		)
+	}
+
+	table := config.Table
+	if table == "" {
+		table = defaultDedupTable
+	}
+	if !tableNamePattern.MatchString(table) {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			fmt.Sprintf("invalid table name: %s", table),
+			"",
+		)
+	}
+	ttl := config.TTL
+	if ttl == 0 {
+		ttl = defaultDedupTTL
+	}
+	lease := config.Lease
+	if lease == 0 {
+		lease = defaultDedupLease
+	}
+
+	return &SQLDedupStore{
+		db:       config.DB,
+		table:    table,
+		numbered: config.NumberedPlaceholders,
+		ttl:      ttl,
+		lease:    lease,
+		now:      time.Now,
+	}, nil
+}
+
+// CreateTable creates the dedup table if it does not exist
+func (s *SQLDedupStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+s.table+" ("+
+		"event_key VARCHAR(191) NOT NULL PRIMARY KEY, "+
+		"status CHAR(1) NOT NULL, "+
+		"expires_at BIGINT NOT NULL)")
+	return err
+}
+
+// Begin implements DedupStore
+func (s *SQLDedupStore) Begin(ctx context.Context, key string) (DedupStatus, error) {
+	now := s.now().UnixMilli()
+	expires := s.now().Add(s.lease).UnixMilli()
+
+	// Take over an expired row first, then try to insert a fresh claim
+	if _, err := s.db.ExecContext(ctx,
+		s.query("DELETE FROM %s WHERE event_key = ? AND expires_at <= ?"), key, now); err != nil {
+		return DedupInProgress, err
+	}
+	_, insertErr := s.db.ExecContext(ctx,
+		s.query("INSERT INTO %s (event_key, status, expires_at) VALUES (?, ?, ?)"),
+		key, dedupStatusInProgress, expires)
+	if insertErr == nil {
+		return DedupNew, nil
+	}
+
+	// The insert failed, most likely on the primary key; report the existing row
+	var status string
+	err := s.db.QueryRowContext(ctx,
+		s.query("SELECT status FROM %s WHERE event_key = ?"), key).Scan(&status)
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return DedupInProgress, insertErr
+	}
+	if err != nil {
+		return DedupInProgress, err
+	}
+	if status == dedupStatusDone {
+		return DedupDone, nil
+	}
+	return DedupInProgress, nil
+}
+
+// Complete implements DedupStore
+func (s *SQLDedupStore) Complete(ctx context.Context, key string) error {
+	expires := s.now().Add(s.ttl).UnixMilli()
+	result, err := s.db.ExecContext(ctx,
+		s.query("UPDATE %s SET status = ?, expires_at = ? WHERE event_key = ?"),
+		dedupStatusDone, expires, key)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		// The claim expired and was taken over meanwhile; remember the outcome anyway
+		_, err = s.db.ExecContext(ctx,
+			s.query("INSERT INTO %s (event_key, status, expires_at) VALUES (?, ?, ?)"),
+			key, dedupStatusDone, expires)
+		return err
+	}
+	return nil
+}
+
+// Release implements DedupStore
+func (s *SQLDedupStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx,
+		s.query("DELETE FROM %s WHERE event_key = ? AND status = ?"), key, dedupStatusInProgress)
+	return err
+}
+
+// Purge deletes expired rows and returns how many were removed
+func (s *SQLDedupStore) Purge(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		s.query("DELETE FROM %s WHERE expires_at <= ?"), s.now().UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// query inserts the table name and rewrites ? placeholders for numbered dialects
+func (s *SQLDedupStore) query(format string) string {
+	q := fmt.Sprintf(format, s.table)
+	if !s.numbered {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
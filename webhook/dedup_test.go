@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryDedupStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryDedupStore(&MemoryDedupConfig{Capacity: 2, TTL: time.Hour, Lease: time.Minute})
+	store.now = func() time.Time { return now }
+
+	steps := []struct {
+		name string
+		run  func() (DedupStatus, error)
+		want DedupStatus
+	}{
+		{"first claim", func() (DedupStatus, error) { return store.Begin(ctx, "a") }, DedupNew},
+		{"concurrent duplicate", func() (DedupStatus, error) { return store.Begin(ctx, "a") }, DedupInProgress},
+		{"after release", func() (DedupStatus, error) {
+			_ = store.Release(ctx, "a")
+			return store.Begin(ctx, "a")
+		}, DedupNew},
+		{"after complete", func() (DedupStatus, error) {
+			_ = store.Complete(ctx, "a")
+			return store.Begin(ctx, "a")
+		}, DedupDone},
+		{"abandoned claim expires", func() (DedupStatus, error) {
+			_, _ = store.Begin(ctx, "b")
+			now = now.Add(2 * time.Minute)
+			return store.Begin(ctx, "b")
+		}, DedupNew},
+		{"lru eviction", func() (DedupStatus, error) {
+			_, _ = store.Begin(ctx, "c")
+			return store.Begin(ctx, "a")
+		}, DedupNew},
+		{"done expires after ttl", func() (DedupStatus, error) {
+			_ = store.Complete(ctx, "c")
+			now = now.Add(2 * time.Hour)
+			return store.Begin(ctx, "c")
+		}, DedupNew},
+	}
+
+	for _, step := range steps {
+		got, err := step.run()
+		if err != nil {
+			t.Fatalf("%s: returned error: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Fatalf("%s: status = %s, want %s", step.name, got, step.want)
+		}
+	}
+	if store.Len() > 2 {
+		t.Fatalf("Len() = %d, want at most 2", store.Len())
+	}
+}
+
+func TestHandlerDeduplicatesNotifications(t *testing.T) {
+	calls := 0
+	fail := true
+	handler, _ := NewHandler(&HandlerConfig{
+		OnEvent: EventHandlerFunc(func(ctx context.Context, event *Event) error {
+			calls++
+			if fail {
+				fail = false
+				return errors.New("database unavailable")
+			}
+			return nil
+		}),
+		DedupStore: NewMemoryDedupStore(nil),
+		Logger:     nopLogger{},
+	})
+
+	wantStatus := []int{http.StatusInternalServerError, http.StatusOK, http.StatusOK}
+	for i, want := range wantStatus {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(saleNotification)))
+		if rec.Code != want {
+			t.Fatalf("delivery %d: status = %d, want %d", i+1, rec.Code, want)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("OnEvent called %d times, want 2", calls)
+	}
+}
+
+func TestSQLDedupStoreQuery(t *testing.T) {
+	store := &SQLDedupStore{table: "dedup", numbered: true}
+	got := store.query("UPDATE %s SET status = ? WHERE event_key = ?")
+	if want := "UPDATE dedup SET status = $1 WHERE event_key = $2"; got != want {
+		t.Fatalf("query() = %q, want %q", got, want)
+	}
+	if _, err := NewSQLDedupStore(&SQLDedupConfig{}); err == nil {
+		t.Fatal("NewSQLDedupStore() expected error for nil DB")
+	}
+}
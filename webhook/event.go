@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/response"
+)
+
+// EventType is the kind of a notification
+type EventType string
+
+const (
+	// EventTypeTransaction is a semi-integrated transaction notification (sale, auth, refund, void, ...)
+	EventTypeTransaction EventType = "TRANSACTION"
+
+	// EventTypeCheckout is an online checkout notification (hosted checkout, direct payment, online refund)
+	EventTypeCheckout EventType = "CHECKOUT"
+
+	// EventTypeBatchClose is a batch close notification
+	EventTypeBatchClose EventType = "BATCH_CLOSE"
+
+	// EventTypeUnknown is a notification that could not be classified
+	EventTypeUnknown EventType = "UNKNOWN"
+)
+
+// String returns the event type code
+func (t EventType) String() string {
+	return string(t)
+}
+
+// TransactionEvent is the payload of a transaction or checkout notification
+// It carries the same fields as a Query response plus the identifiers of the original transaction
+type TransactionEvent struct {
+	response.QueryResponse
+
+	// AppID is the application ID
+	AppID string `json:"appId,omitempty"`
+
+	// MerchantID is the merchant ID
+	MerchantID string `json:"merchantId,omitempty"`
+
+	// OriginalTransactionID is the original transaction ID (refund, void, post-auth, incremental auth)
+	OriginalTransactionID string `json:"originalTransactionId,omitempty"`
+
+	// OriginalTransactionRequestID is the original transaction request ID (refund, void, post-auth, incremental auth)
+	OriginalTransactionRequestID string `json:"originalTransactionRequestId,omitempty"`
+}
+
+// BatchCloseEvent is the payload of a batch close notification
+type BatchCloseEvent struct {
+	response.BatchCloseResponse
+
+	// AppID is the application ID
+	AppID string `json:"appId,omitempty"`
+
+	// MerchantID is the merchant ID
+	MerchantID string `json:"merchantId,omitempty"`
+
+	// TransactionRequestID is the batch close request ID
+	TransactionRequestID string `json:"transactionRequestId,omitempty"`
+}
+
+// Event is a parsed Nexus notification
+type Event struct {
+	// Type is the kind of notification
+	Type EventType
+
+	// Transaction is set for EventTypeTransaction and EventTypeCheckout
+	Transaction *TransactionEvent
+
+	// BatchClose is set for EventTypeBatchClose
+	BatchClose *BatchCloseEvent
+
+	// Raw is the notification payload (the content of the data field when wrapped)
+	Raw json.RawMessage
+}
+
+// ParseEvent parses a notification body into a typed event
+// Both flat payloads and payloads wrapped in a data field are supported. When the payload has no
+// eventType field, the type is inferred: a transactionId means a transaction notification and a
+// batchNo without transactionId means a batch close notification
+func ParseEvent(body []byte) (*Event, error) {
+	var wrapper struct {
+		EventType string          `json:"eventType"`
+		Data      json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return nil, fmt.Errorf("parse notification: %w", err)
+	}
+
+	payload := body
+	if len(wrapper.Data) > 0 && !bytes.Equal(wrapper.Data, []byte("null")) {
+		payload = wrapper.Data
+	}
+
+	var probe struct {
+		EventType     string `json:"eventType"`
+		TransactionID string `json:"transactionId"`
+		BatchNo       string `json:"batchNo"`
+	}
+	if err := json.Unmarshal(payload, &probe); err != nil {
+		return nil, fmt.Errorf("parse notification data: %w", err)
+	}
+
+	eventType := EventType(strings.ToUpper(wrapper.EventType))
+	if eventType == "" {
+		eventType = EventType(strings.ToUpper(probe.EventType))
+	}
+	switch eventType {
+	case EventTypeTransaction, EventTypeCheckout, EventTypeBatchClose:
+	default:
+		switch {
+		case probe.TransactionID != "":
+			eventType = EventTypeTransaction
+		case probe.BatchNo != "":
+			eventType = EventTypeBatchClose
+		default:
+			eventType = EventTypeUnknown
+		}
+	}
+
+	event := &Event{
+		Type: eventType,
+		Raw:  json.RawMessage(payload),
+	}
+	switch eventType {
+	case EventTypeTransaction, EventTypeCheckout:
+		event.Transaction = &TransactionEvent{}
+		if err := json.Unmarshal(payload, event.Transaction); err != nil {
+			return nil, fmt.Errorf("parse transaction notification: %w", err)
+		}
+	case EventTypeBatchClose:
+		event.BatchClose = &BatchCloseEvent{}
+		if err := json.Unmarshal(payload, event.BatchClose); err != nil {
+			return nil, fmt.Errorf("parse batch close notification: %w", err)
+		}
+	}
+	return event, nil
+}
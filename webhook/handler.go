@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+	sdkhttp "github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/util"
+)
+
+const (
+	defaultMaxBodySize = 1 << 20
+	ackMessageSuccess  = "success"
+	ackCodeFailure     = "FAIL"
+)
+
+// Logger is the logging interface that allows integration with any logging library
+type Logger interface {
+	Debug(args ...interface{})
+	Debugf(format string, args ...interface{})
+	Info(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warn(args ...interface{})
+	Warnf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// EventHandler processes a parsed notification
+// Returning an error makes the Handler answer with a failure so the platform delivers the notification again
+type EventHandler interface {
+	HandleEvent(ctx context.Context, event *Event) error
+}
+
+// EventHandlerFunc adapts a function to the EventHandler interface
+type EventHandlerFunc func(ctx context.Context, event *Event) error
+
+// HandleEvent implements EventHandler
+func (f EventHandlerFunc) HandleEvent(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// HandlerConfig holds the configuration for creating a Handler
+type HandlerConfig struct {
+	// OnEvent receives every parsed notification (required)
+	OnEvent EventHandler
+
+	// Verifier rejects notifications whose signature or timestamp is invalid (optional, strongly recommended)
+	// When nil, notifications are accepted without authenticity checks
+	Verifier *Verifier
+
+	// DedupStore makes the Handler dispatch each event (see DedupKey) only once (optional)
+	// Duplicates of processed events are acknowledged without dispatch, duplicates arriving while
+	// the event is still being processed are answered with a failure so they are delivered again
+	DedupStore DedupStore
+
+	// MaxBodySize is the maximum accepted notification body size in bytes (optional, defaults to 1 MiB)
+	MaxBodySize int64
+
+	// Logger is a custom logger implementation (optional, defaults to console logger)
+	Logger Logger
+}
+
+// Handler is a net/http Handler receiving Nexus notifications sent to the notifyUrl of a request
+// Mount it on the path used as NotifyURL. It is safe for concurrent use
+type Handler struct {
+	onEvent     EventHandler
+	verifier    *Verifier
+	dedup       DedupStore
+	maxBodySize int64
+	logger      Logger
+}
+
+// ack is the acknowledgement body returned to the platform
+type ack struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// NewHandler creates a notification Handler with the given configuration
+func NewHandler(config *HandlerConfig) (*Handler, error) {
+	if config == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"HandlerConfig cannot be nil",
+			"",
+		)
+	}
+	if config.OnEvent == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"OnEvent cannot be nil",
+			"",
+		)
+	}
+
+	maxBodySize := config.MaxBodySize
+	if maxBodySize == 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = sdkhttp.DefaultLogger()
+	}
+
+	return &Handler{
+		onEvent:     config.OnEvent,
+		verifier:    config.Verifier,
+		dedup:       config.DedupStore,
+		maxBodySize: maxBodySize,
+		logger:      logger,
+	}, nil
+}
+
+// ServeHTTP implements http.Handler
+// It answers 200 with {"code":"0","msg":"success"} once the notification was processed,
+// 401 for notifications failing verification, 400 for malformed notifications
+// and 500 when processing failed and the notification should be redelivered
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.respond(w, http.StatusMethodNotAllowed, constant.ErrorCodeParameterError, "method not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, h.maxBodySize+1))
+	if err != nil {
+		h.logger.Warnf("Notification read failed: %v", err)
+		h.respond(w, http.StatusBadRequest, constant.ErrorCodeParameterError, "failed to read body")
+		return
+	}
+	if int64(len(body)) > h.maxBodySize {
+		h.logger.Warnf("Notification rejected: body exceeds %d bytes", h.maxBodySize)
+		h.respond(w, http.StatusRequestEntityTooLarge, constant.ErrorCodeParameterError, "body too large")
+		return
+	}
+
+	if h.verifier != nil {
+		if err := h.verifier.Verify(r.Header, body); err != nil {
+			h.logger.Warnf("Notification rejected: %v", err)
+			h.respond(w, http.StatusUnauthorized, constant.ErrorCodeParameterError, "verification failed")
+			return
+		}
+	}
+
+	event, err := ParseEvent(body)
+	if err != nil {
+		h.logger.Warnf("Notification rejected: %v", err)
+		h.respond(w, http.StatusBadRequest, constant.ErrorCodeParameterError, "malformed notification")
+		return
+	}
+
+	h.logger.Infof("Notification received - Type: %s, %s", event.Type, eventIDs(event))
+	if redacted, err := util.RedactJSON(body); err == nil {
+		h.logger.Debugf("Notification body: %s", redacted)
+	}
+
+	ctx := r.Context()
+	key := ""
+	if h.dedup != nil {
+		key = DedupKey(event)
+	}
+	if key != "" {
+		status, err := h.dedup.Begin(ctx, key)
+		if err != nil {
+			h.logger.Errorf("Notification dedup check failed - Key: %s: %v", key, err)
+			h.respond(w, http.StatusInternalServerError, ackCodeFailure, "processing failed")
+			return
+		}
+		switch status {
+		case DedupDone:
+			h.logger.Infof("Duplicate notification acknowledged - Key: %s", key)
+			h.respond(w, http.StatusOK, constant.ResponseSuccessCode, ackMessageSuccess)
+			return
+		case DedupInProgress:
+			h.logger.Infof("Duplicate notification still in progress - Key: %s", key)
+			h.respond(w, http.StatusInternalServerError, ackCodeFailure, "processing in progress")
+			return
+		}
+	}
+
+	if err := h.onEvent.HandleEvent(ctx, event); err != nil {
+		h.logger.Errorf("Notification processing failed - Type: %s: %v", event.Type, err)
+		if key != "" {
+			if err := h.dedup.Release(context.Background(), key); err != nil {
+				h.logger.Errorf("Notification dedup release failed - Key: %s: %v", key, err)
+			}
+		}
+		h.respond(w, http.StatusInternalServerError, ackCodeFailure, "processing failed")
+		return
+	}
+
+	if key != "" {
+		if err := h.dedup.Complete(context.Background(), key); err != nil {
+			h.logger.Errorf("Notification dedup completion failed - Key: %s: %v", key, err)
+		}
+	}
+	h.respond(w, http.StatusOK, constant.ResponseSuccessCode, ackMessageSuccess)
+}
+
+// respond writes the acknowledgement body
+func (h *Handler) respond(w http.ResponseWriter, status int, code, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ack{Code: code, Msg: msg})
+}
+
+// eventIDs describes the identifiers of an event for logging; the body may hold card and customer data
+func eventIDs(event *Event) string {
+	switch {
+	case event.Transaction != nil:
+		return fmt.Sprintf("TransactionID: %s, TransactionRequestID: %s, Status: %s",
+			event.Transaction.TransactionID, event.Transaction.TransactionRequestID, event.Transaction.TransactionStatus)
+	case event.BatchClose != nil:
+		return fmt.Sprintf("TerminalSN: %s, BatchNo: %s", event.BatchClose.TerminalSN, event.BatchClose.BatchNo)
+	default:
+		return "no identifiers"
+	}
+}
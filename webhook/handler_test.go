@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(args ...interface{})                 {}
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+func (nopLogger) Info(args ...interface{})                  {}
+func (nopLogger) Infof(format string, args ...interface{})  {}
+func (nopLogger) Warn(args ...interface{})                  {}
+func (nopLogger) Warnf(format string, args ...interface{})  {}
+func (nopLogger) Error(args ...interface{})                 {}
+func (nopLogger) Errorf(format string, args ...interface{}) {}
+
+const saleNotification = `{"transactionId":"TX_1","transactionRequestId":"REQ_1","transactionStatus":"S","transactionType":"SALE","amount":{"priceCurrency":"USD","orderAmount":1000},"merchantId":"mch"}`
+
+func TestParseEvent(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want EventType
+	}{
+		{"flat transaction", saleNotification, EventTypeTransaction},
+		{"wrapped transaction", `{"eventType":"TRANSACTION","data":` + saleNotification + `}`, EventTypeTransaction},
+		{"explicit checkout", `{"eventType":"checkout","transactionId":"TX_2","transactionStatus":"S"}`, EventTypeCheckout},
+		{"batch close", `{"batchNo":"B1","terminalSn":"T1","transactionCount":3,"netAmount":3000}`, EventTypeBatchClose},
+		{"unknown", `{"foo":"bar"}`, EventTypeUnknown},
+	}
+
+	for _, tc := range cases {
+		event, err := ParseEvent([]byte(tc.body))
+		if err != nil {
+			t.Fatalf("%s: ParseEvent() returned error: %v", tc.name, err)
+		}
+		if event.Type != tc.want {
+			t.Fatalf("%s: Type = %s, want %s", tc.name, event.Type, tc.want)
+		}
+	}
+
+	event, _ := ParseEvent([]byte(saleNotification))
+	tx := event.Transaction
+	if tx.TransactionID != "TX_1" || tx.TransactionStatus != types.TransactionStatusSuccess ||
+		tx.TransactionType != types.TransactionTypeSale || tx.MerchantID != "mch" || *tx.Amount.OrderAmount != 1000 {
+		t.Fatalf("unexpected transaction event: %+v", tx)
+	}
+
+	if _, err := ParseEvent([]byte(`not json`)); err == nil {
+		t.Fatal("ParseEvent() expected error for malformed body")
+	}
+}
+
+func TestHandlerServeHTTP(t *testing.T) {
+	var received *Event
+	failWith := error(nil)
+	handler, err := NewHandler(&HandlerConfig{
+		OnEvent: EventHandlerFunc(func(ctx context.Context, event *Event) error {
+			received = event
+			return failWith
+		}),
+		Logger: nopLogger{},
+	})
+	if err != nil {
+		t.Fatalf("NewHandler() returned error: %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(saleNotification)
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"code":"0","msg":"success"}` {
+		t.Fatalf("unexpected ack: %d %s", rec.Code, rec.Body.String())
+	}
+	if received == nil || received.Transaction.TransactionID != "TX_1" {
+		t.Fatalf("callback not invoked with event: %+v", received)
+	}
+
+	if rec := post(`not json`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body: status = %d, want 400", rec.Code)
+	}
+
+	failWith = fmt.Errorf("database down")
+	if rec := post(saleNotification); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("failing callback: status = %d, want 500", rec.Code)
+	}
+}
+
+// recordingLogger keeps the formatted messages per level
+type recordingLogger struct {
+	nopLogger
+	mu    sync.Mutex
+	info  []string
+	debug []string
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.info = append(l.info, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debug = append(l.debug, fmt.Sprintf(format, args...))
+}
+
+func TestHandlerRedactsLoggedBody(t *testing.T) {
+	logger := &recordingLogger{}
+	handler, err := NewHandler(&HandlerConfig{
+		OnEvent: EventHandlerFunc(func(ctx context.Context, event *Event) error { return nil }),
+		Logger:  logger,
+	})
+	if err != nil {
+		t.Fatalf("NewHandler() returned error: %v", err)
+	}
+
+	body := `{"eventType":"TRANSACTION","data":{"transactionId":"TX_1","transactionStatus":"S","customerEmail":"jane@example.com","amount":{"orderAmount":1000}}}`
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body)))
+
+	info := strings.Join(logger.info, "\n")
+	if !strings.Contains(info, "TX_1") || strings.Contains(info, "jane@example.com") || strings.Contains(info, "orderAmount") {
+		t.Fatalf("Info logs = %q, want the transaction ID without the body", info)
+	}
+	debug := strings.Join(logger.debug, "\n")
+	if !strings.Contains(debug, `"customerEmail":"[REDACTED]"`) || strings.Contains(debug, "jane@example.com") {
+		t.Fatalf("Debug logs = %q, want the body with customer data redacted", debug)
+	}
+}
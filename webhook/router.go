@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	sdkhttp "github.com/sunbay-developer/sunbay-nexus-sdk-go/http"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+// TransactionHandler processes a transaction or checkout notification
+type TransactionHandler func(ctx context.Context, event *TransactionEvent) error
+
+// BatchCloseHandler processes a batch close notification
+type BatchCloseHandler func(ctx context.Context, event *BatchCloseEvent) error
+
+// RouterConfig holds the configuration for creating a Router
+type RouterConfig struct {
+	// Fallback receives events no route matches, including unknown event kinds (optional)
+	// When nil, unmatched events are logged and acknowledged
+	Fallback EventHandler
+
+	// OnPanic decides how a recovered handler panic is answered (optional)
+	// Returning nil acknowledges the notification, returning an error asks for redelivery.
+	// When nil, the panic is logged and an error returned, so the notification is delivered again
+	// and a DedupStore does not mark the event processed
+	OnPanic func(ctx context.Context, event *Event, recovered interface{}) error
+
+	// Logger is a custom logger implementation (optional, defaults to console logger)
+	Logger Logger
+}
+
+// routeKey identifies a transaction route; empty fields match any value
+type routeKey struct {
+	eventType       EventType
+	transactionType types.TransactionType
+	status          types.TransactionStatus
+}
+
+// Router is an EventHandler dispatching notifications to handlers registered per
+// event type, types.TransactionType and types.TransactionStatus
+// The most specific route wins: type and status, then type only, then status only.
+// A panicking handler is recovered so it cannot crash the whole endpoint. It is safe for concurrent use
+type Router struct {
+	mu           sync.RWMutex
+	transactions map[routeKey]TransactionHandler
+	batchClose   BatchCloseHandler
+	fallback     EventHandler
+	onPanic      func(ctx context.Context, event *Event, recovered interface{}) error
+	logger       Logger
+}
+
+// NewRouter creates a notification Router with the given configuration (nil uses defaults)
+func NewRouter(config *RouterConfig) *Router {
+	if config == nil {
+		config = &RouterConfig{}
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = sdkhttp.DefaultLogger()
+	}
+
+	return &Router{
+		transactions: make(map[routeKey]TransactionHandler),
+		fallback:     config.Fallback,
+		onPanic:      config.OnPanic,
+		logger:       logger,
+	}
+}
+
+// OnTransaction registers a handler for semi-integrated transaction notifications
+// An empty transactionType or status matches any value. A later registration replaces an earlier one
+func (r *Router) OnTransaction(transactionType types.TransactionType, status types.TransactionStatus, handler TransactionHandler) {
+	r.handle(routeKey{EventTypeTransaction, transactionType, status}, handler)
+}
+
+// OnCheckout registers a handler for online checkout notifications
+// An empty transactionType or status matches any value. A later registration replaces an earlier one
+func (r *Router) OnCheckout(transactionType types.TransactionType, status types.TransactionStatus, handler TransactionHandler) {
+	r.handle(routeKey{EventTypeCheckout, transactionType, status}, handler)
+}
+
+// OnSaleSucceeded registers a handler for successful sales
+func (r *Router) OnSaleSucceeded(handler TransactionHandler) {
+	r.OnTransaction(types.TransactionTypeSale, types.TransactionStatusSuccess, handler)
+}
+
+// OnSaleFailed registers a handler for failed sales
+func (r *Router) OnSaleFailed(handler TransactionHandler) {
+	r.OnTransaction(types.TransactionTypeSale, types.TransactionStatusFail, handler)
+}
+
+// OnAuthSucceeded registers a handler for successful authorizations
+func (r *Router) OnAuthSucceeded(handler TransactionHandler) {
+	r.OnTransaction(types.TransactionTypeAuth, types.TransactionStatusSuccess, handler)
+}
+
+// OnIncrementalAuthSucceeded registers a handler for successful incremental authorizations
+func (r *Router) OnIncrementalAuthSucceeded(handler TransactionHandler) {
+	r.OnTransaction(types.TransactionTypeIncremental, types.TransactionStatusSuccess, handler)
+}
+
+// OnPostAuthCompleted registers a handler for successful post authorizations (captures)
+func (r *Router) OnPostAuthCompleted(handler TransactionHandler) {
+	r.OnTransaction(types.TransactionTypePostAuth, types.TransactionStatusSuccess, handler)
+}
+
+// OnRefundSucceeded registers a handler for successful refunds
+func (r *Router) OnRefundSucceeded(handler TransactionHandler) {
+	r.OnTransaction(types.TransactionTypeRefund, types.TransactionStatusSuccess, handler)
+}
+
+// OnRefundFailed registers a handler for failed refunds
+func (r *Router) OnRefundFailed(handler TransactionHandler) {
+	r.OnTransaction(types.TransactionTypeRefund, types.TransactionStatusFail, handler)
+}
+
+// OnVoidSucceeded registers a handler for successful voids
+func (r *Router) OnVoidSucceeded(handler TransactionHandler) {
+	r.OnTransaction(types.TransactionTypeVoid, types.TransactionStatusSuccess, handler)
+}
+
+// OnCheckoutPaid registers a handler for successful checkout payments
+func (r *Router) OnCheckoutPaid(handler TransactionHandler) {
+	r.OnCheckout(types.TransactionTypeSale, types.TransactionStatusSuccess, handler)
+}
+
+// OnCheckoutFailed registers a handler for failed checkout payments
+func (r *Router) OnCheckoutFailed(handler TransactionHandler) {
+	r.OnCheckout(types.TransactionTypeSale, types.TransactionStatusFail, handler)
+}
+
+// OnBatchClosed registers the handler for batch close notifications
+func (r *Router) OnBatchClosed(handler BatchCloseHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batchClose = handler
+}
+
+// OnUnmatched sets the fallback handler for events no route matches, including unknown event kinds
+func (r *Router) OnUnmatched(handler EventHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = handler
+}
+
+func (r *Router) handle(key routeKey, handler TransactionHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if handler == nil {
+		delete(r.transactions, key)
+		return
+	}
+	r.transactions[key] = handler
+}
+
+// HandleEvent implements EventHandler
+func (r *Router) HandleEvent(ctx context.Context, event *Event) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			r.logger.Errorf("Notification handler panicked - Type: %s: %v\n%s", event.Type, recovered, debug.Stack())
+			if r.onPanic != nil {
+				err = r.onPanic(ctx, event, recovered)
+				return
+			}
+			err = fmt.Errorf("notification handler panicked: %v", recovered)
+		}
+	}()
+
+	switch event.Type {
+	case EventTypeTransaction, EventTypeCheckout:
+		if handler := r.transactionRoute(event); handler != nil {
+			return handler(ctx, event.Transaction)
+		}
+	case EventTypeBatchClose:
+		r.mu.RLock()
+		handler := r.batchClose
+		r.mu.RUnlock()
+		if handler != nil && event.BatchClose != nil {
+			return handler(ctx, event.BatchClose)
+		}
+	}
+
+	r.mu.RLock()
+	fallback := r.fallback
+	r.mu.RUnlock()
+	if fallback != nil {
+		return fallback.HandleEvent(ctx, event)
+	}
+	r.logger.Debugf("Notification not routed - Type: %s", event.Type)
+	return nil
+}
+
+// transactionRoute returns the most specific handler matching a transaction event, or nil
+func (r *Router) transactionRoute(event *Event) TransactionHandler {
+	tx := event.Transaction
+	if tx == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, key := range []routeKey{
+		{event.Type, tx.TransactionType, tx.TransactionStatus},
+		{event.Type, tx.TransactionType, ""},
+		{event.Type, "", tx.TransactionStatus},
+		{event.Type, "", ""},
+	} {
+		if handler, ok := r.transactions[key]; ok {
+			return handler
+		}
+	}
+	return nil
+}
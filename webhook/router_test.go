@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/model/types"
+)
+
+func TestRouterDispatch(t *testing.T) {
+	var got []string
+	record := func(name string) TransactionHandler {
+		return func(ctx context.Context, event *TransactionEvent) error {
+			got = append(got, name)
+			return nil
+		}
+	}
+
+	router := NewRouter(&RouterConfig{Logger: nopLogger{}})
+	router.OnSaleSucceeded(record("sale-succeeded"))
+	router.OnRefundFailed(record("refund-failed"))
+	router.OnTransaction(types.TransactionTypeRefund, "", record("refund-any"))
+	router.OnTransaction("", types.TransactionStatusFail, record("any-failed"))
+	router.OnCheckoutPaid(record("checkout-paid"))
+	router.OnBatchClosed(func(ctx context.Context, event *BatchCloseEvent) error {
+		got = append(got, "batch-closed")
+		return nil
+	})
+	router.OnUnmatched(EventHandlerFunc(func(ctx context.Context, event *Event) error {
+		got = append(got, "fallback-"+event.Type.String())
+		return nil
+	}))
+
+	cases := []struct {
+		body string
+		want string
+	}{
+		{saleNotification, "sale-succeeded"},
+		{`{"transactionId":"TX_2","transactionStatus":"F","transactionType":"REFUND"}`, "refund-failed"},
+		{`{"transactionId":"TX_3","transactionStatus":"S","transactionType":"REFUND"}`, "refund-any"},
+		{`{"transactionId":"TX_4","transactionStatus":"F","transactionType":"VOID"}`, "any-failed"},
+		{`{"eventType":"CHECKOUT","transactionId":"TX_5","transactionStatus":"S","transactionType":"SALE"}`, "checkout-paid"},
+		{`{"transactionId":"TX_6","transactionStatus":"S","transactionType":"AUTH"}`, "fallback-TRANSACTION"},
+		{`{"batchNo":"B1","terminalSn":"T1"}`, "batch-closed"},
+		{`{"foo":"bar"}`, "fallback-UNKNOWN"},
+	}
+
+	for _, tc := range cases {
+		got = nil
+		event, err := ParseEvent([]byte(tc.body))
+		if err != nil {
+			t.Fatalf("ParseEvent(%s) returned error: %v", tc.body, err)
+		}
+		if err := router.HandleEvent(context.Background(), event); err != nil {
+			t.Fatalf("HandleEvent(%s) returned error: %v", tc.body, err)
+		}
+		if len(got) != 1 || got[0] != tc.want {
+			t.Fatalf("HandleEvent(%s) dispatched to %v, want [%s]", tc.body, got, tc.want)
+		}
+	}
+}
+
+func TestRouterRecoversPanic(t *testing.T) {
+	router := NewRouter(&RouterConfig{Logger: nopLogger{}})
+	router.OnSaleSucceeded(func(ctx context.Context, event *TransactionEvent) error {
+		panic("boom")
+	})
+	handler, _ := NewHandler(&HandlerConfig{OnEvent: router, Logger: nopLogger{}})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(saleNotification)))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d so the notification is delivered again", rec.Code, http.StatusInternalServerError)
+	}
+
+	// Acknowledging a panic is opt-in
+	router = NewRouter(&RouterConfig{
+		Logger:  nopLogger{},
+		OnPanic: func(ctx context.Context, event *Event, recovered interface{}) error { return nil },
+	})
+	router.OnSaleSucceeded(func(ctx context.Context, event *TransactionEvent) error {
+		panic("boom")
+	})
+	handler, _ = NewHandler(&HandlerConfig{OnEvent: router, Logger: nopLogger{}})
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(saleNotification)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d when OnPanic acknowledges", rec.Code, http.StatusOK)
+	}
+}
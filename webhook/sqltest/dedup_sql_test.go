@@ -0,0 +1,96 @@
+//go:build cgo
+
+// Package sqltest runs the SQL dedup store against SQLite. It is a separate module so the
+// cgo driver is not a dependency of the SDK
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/webhook"
+)
+
+const (
+	lease = 200 * time.Millisecond
+	ttl   = 4 * lease
+)
+
+func TestSQLDedupStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "dedup.db"))
+	if err != nil {
+		t.Fatalf("sql.Open() returned error: %v", err)
+	}
+	defer db.Close()
+
+	store, err := webhook.NewSQLDedupStore(&webhook.SQLDedupConfig{DB: db, TTL: ttl, Lease: lease})
+	if err != nil {
+		t.Fatalf("NewSQLDedupStore() returned error: %v", err)
+	}
+	if err := store.CreateTable(ctx); err != nil {
+		t.Fatalf("CreateTable() returned error: %v", err)
+	}
+
+	steps := []struct {
+		name string
+		run  func() (webhook.DedupStatus, error)
+		want webhook.DedupStatus
+	}{
+		{"first claim", func() (webhook.DedupStatus, error) { return store.Begin(ctx, "a") }, webhook.DedupNew},
+		{"concurrent duplicate", func() (webhook.DedupStatus, error) { return store.Begin(ctx, "a") }, webhook.DedupInProgress},
+		{"after release", func() (webhook.DedupStatus, error) {
+			_ = store.Release(ctx, "a")
+			return store.Begin(ctx, "a")
+		}, webhook.DedupNew},
+		{"after complete", func() (webhook.DedupStatus, error) {
+			_ = store.Complete(ctx, "a")
+			return store.Begin(ctx, "a")
+		}, webhook.DedupDone},
+		{"release keeps a processed key", func() (webhook.DedupStatus, error) {
+			_ = store.Release(ctx, "a")
+			return store.Begin(ctx, "a")
+		}, webhook.DedupDone},
+		{"abandoned claim expires", func() (webhook.DedupStatus, error) {
+			_, _ = store.Begin(ctx, "b")
+			time.Sleep(lease + lease/2)
+			return store.Begin(ctx, "b")
+		}, webhook.DedupNew},
+		{"complete after the claim was purged", func() (webhook.DedupStatus, error) {
+			_, _ = store.Begin(ctx, "c")
+			time.Sleep(lease + lease/2)
+			if _, err := store.Purge(ctx); err != nil {
+				return webhook.DedupInProgress, err
+			}
+			if err := store.Complete(ctx, "c"); err != nil {
+				return webhook.DedupInProgress, err
+			}
+			return store.Begin(ctx, "c")
+		}, webhook.DedupDone},
+		{"done expires after ttl", func() (webhook.DedupStatus, error) {
+			time.Sleep(ttl + lease/2)
+			return store.Begin(ctx, "c")
+		}, webhook.DedupNew},
+	}
+
+	for _, step := range steps {
+		got, err := step.run()
+		if err != nil {
+			t.Fatalf("%s: returned error: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Fatalf("%s: status = %s, want %s", step.name, got, step.want)
+		}
+	}
+
+	// b was purged above; the processed a and the claim on c have expired by now
+	time.Sleep(lease + lease/2)
+	if n, err := store.Purge(ctx); err != nil || n != 2 {
+		t.Fatalf("Purge() = %d, %v; want 2 expired rows", n, err)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/sunbay-developer/sunbay-nexus-sdk-go/webhook/sqltest
+
+go 1.18
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.19
+	github.com/sunbay-developer/sunbay-nexus-sdk-go v0.0.0
+)
+
+replace github.com/sunbay-developer/sunbay-nexus-sdk-go => ../..
@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/constant"
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+)
+
+const (
+	// HeaderSignature is the notification header carrying the signature
+	HeaderSignature = "X-Signature"
+
+	// HeaderTimestamp is the notification header carrying the signing time in Unix milliseconds
+	HeaderTimestamp = "X-Timestamp"
+
+	defaultTolerance = 5 * time.Minute
+)
+
+// VerifierConfig holds the configuration for creating a Verifier
+type VerifierConfig struct {
+	// Secret is the merchant key used to sign notifications (required)
+	Secret string
+
+	// Tolerance is the maximum accepted difference between the notification timestamp
+	// and the local clock, bounding the replay window (optional, defaults to 5m)
+	Tolerance time.Duration
+}
+
+// Verifier checks the authenticity of notifications
+// The signature is the lowercase hex HMAC-SHA256, keyed with the merchant key, of the
+// timestamp header value, a dot and the raw body. It is safe for concurrent use
+type Verifier struct {
+	secret    []byte
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+// NewVerifier creates a notification Verifier with the given configuration
+func NewVerifier(config *VerifierConfig) (*Verifier, error) {
+	if config == nil {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"VerifierConfig cannot be nil",
+			"",
+		)
+	}
+	if config.Secret == "" {
+		return nil, errors.NewBusinessError(
+			constant.ErrorCodeParameterError,
+			"Secret cannot be empty",
+			"",
+		)
+	}
+
+	tolerance := config.Tolerance
+	if tolerance == 0 {
+		tolerance = defaultTolerance
+	}
+
+	return &Verifier{
+		secret:    []byte(config.Secret),
+		tolerance: tolerance,
+		now:       time.Now,
+	}, nil
+}
+
+// Verify checks the signature and timestamp headers of a notification against its raw body
+// It returns an *errors.VerificationError when the notification must be rejected
+func (v *Verifier) Verify(header http.Header, body []byte) error {
+	return v.VerifySignature(header.Get(HeaderSignature), header.Get(HeaderTimestamp), body)
+}
+
+// VerifySignature checks a signature and timestamp against the raw body
+// It returns an *errors.VerificationError when the notification must be rejected
+func (v *Verifier) VerifySignature(signature, timestamp string, body []byte) error {
+	if signature == "" {
+		return errors.NewVerificationError(errors.VerificationReasonMissingSignature, "signature header is missing")
+	}
+	if timestamp == "" {
+		return errors.NewVerificationError(errors.VerificationReasonMissingTimestamp, "timestamp header is missing")
+	}
+
+	millis, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.NewVerificationError(errors.VerificationReasonInvalidTimestamp, "timestamp is not a number")
+	}
+	skew := v.now().Sub(time.UnixMilli(millis))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > v.tolerance {
+		return errors.NewVerificationError(
+			errors.VerificationReasonExpired,
+			fmt.Sprintf("timestamp differs from local clock by %v, tolerance is %v", skew.Round(time.Second), v.tolerance),
+		)
+	}
+
+	expected := sign(v.secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return errors.NewVerificationError(errors.VerificationReasonInvalidSignature, "signature does not match")
+	}
+	return nil
+}
+
+// Sign computes the signature of a notification body, e.g. to simulate notifications in tests
+func Sign(secret, timestamp string, body []byte) string {
+	return sign([]byte(secret), timestamp, body)
+}
+
+// sign computes the hex HMAC-SHA256 of timestamp + "." + body
+func sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sunbay-developer/sunbay-nexus-sdk-go/errors"
+)
+
+func TestVerifierVerifySignature(t *testing.T) {
+	now := time.Now()
+	verifier, err := NewVerifier(&VerifierConfig{Secret: "merchant-key", Tolerance: time.Minute})
+	if err != nil {
+		t.Fatalf("NewVerifier() returned error: %v", err)
+	}
+	verifier.now = func() time.Time { return now }
+
+	body := []byte(saleNotification)
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	stale := strconv.FormatInt(now.Add(-2*time.Minute).UnixMilli(), 10)
+
+	cases := []struct {
+		name      string
+		signature string
+		timestamp string
+		body      []byte
+		reason    string
+	}{
+		{"valid", Sign("merchant-key", timestamp, body), timestamp, body, ""},
+		{"missing signature", "", timestamp, body, errors.VerificationReasonMissingSignature},
+		{"missing timestamp", Sign("merchant-key", timestamp, body), "", body, errors.VerificationReasonMissingTimestamp},
+		{"malformed timestamp", Sign("merchant-key", "abc", body), "abc", body, errors.VerificationReasonInvalidTimestamp},
+		{"replayed", Sign("merchant-key", stale, body), stale, body, errors.VerificationReasonExpired},
+		{"wrong key", Sign("other-key", timestamp, body), timestamp, body, errors.VerificationReasonInvalidSignature},
+		{"tampered body", Sign("merchant-key", timestamp, body), timestamp, []byte(`{"transactionStatus":"S"}`), errors.VerificationReasonInvalidSignature},
+	}
+
+	for _, tc := range cases {
+		err := verifier.VerifySignature(tc.signature, tc.timestamp, tc.body)
+		if tc.reason == "" {
+			if err != nil {
+				t.Fatalf("%s: VerifySignature() returned error: %v", tc.name, err)
+			}
+			continue
+		}
+		verr, ok := err.(*errors.VerificationError)
+		if !ok || verr.Reason() != tc.reason {
+			t.Fatalf("%s: VerifySignature() error = %v, want reason %s", tc.name, err, tc.reason)
+		}
+	}
+}
+
+func TestHandlerRejectsUnverifiedNotification(t *testing.T) {
+	verifier, _ := NewVerifier(&VerifierConfig{Secret: "merchant-key"})
+	called := false
+	handler, _ := NewHandler(&HandlerConfig{
+		OnEvent:  EventHandlerFunc(func(ctx context.Context, event *Event) error { called = true; return nil }),
+		Verifier: verifier,
+		Logger:   nopLogger{},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(saleNotification))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(time.Now().UnixMilli(), 10))
+	req.Header.Set(HeaderSignature, "deadbeef")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized || called {
+		t.Fatalf("status = %d, called = %v; want 401 without dispatch", rec.Code, called)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	req = httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(saleNotification))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign("merchant-key", timestamp, []byte(saleNotification)))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || !called {
+		t.Fatalf("status = %d, called = %v; want 200 with dispatch", rec.Code, called)
+	}
+}